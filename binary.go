@@ -0,0 +1,233 @@
+package vclock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// The compact binary encoding of a vector clock looks like this:
+//
+//	magic (1 byte) | version (1 byte) | count (uvarint) | entries...
+//
+// where each of the count entries is
+//
+//	id length (uvarint) | id (bytes) | ticks (uvarint)
+//
+// and the entries are sorted by id in ascending order. The magic byte can never
+// be the first byte of a gob stream, which is what allows FromBytes to tell the
+// compact encoding apart from the gob encoding used by earlier versions.
+const (
+	binaryMagic   byte = 0xc7
+	binaryVersion byte = 1
+)
+
+// errTruncated is returned when a binary encoded clock ends prematurely.
+var errTruncated = errors.New("vclock: binary clock is truncated")
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface using the
+// compact binary encoding of vector clocks.
+func (vc VClock) MarshalBinary() ([]byte, error) {
+	return vc.AppendBytes(make([]byte, 0, vc.binarySize()))
+}
+
+// AppendBytes appends the compact binary encoding of the vector clock to dst
+// and returns the extended buffer. It allows callers to reuse their buffers
+// instead of allocating a new one for every clock.
+func (vc VClock) AppendBytes(dst []byte) ([]byte, error) {
+	ids := vc.sortedIDs()
+
+	dst = append(dst, binaryMagic, binaryVersion)
+	dst = binary.AppendUvarint(dst, uint64(len(ids)))
+	for _, id := range ids {
+		dst = binary.AppendUvarint(dst, uint64(len(id)))
+		dst = append(dst, id...)
+		dst = binary.AppendUvarint(dst, vc[id])
+	}
+	return dst, nil
+}
+
+// binarySize returns the size of the binary encoding.
+func (vc VClock) binarySize() int {
+	size := 2 + uvarintLen(uint64(len(vc)))
+	for id, ticks := range vc {
+		size += uvarintLen(uint64(len(id))) + len(id) + uvarintLen(ticks)
+	}
+	return size
+}
+
+// uvarintLen returns the number of bytes of the uvarint encoding of x.
+func uvarintLen(x uint64) int {
+	n := 1
+	for ; x >= 0x80; x >>= 7 {
+		n++
+	}
+	return n
+}
+
+// WriteTo implements the io.WriterTo interface. It writes the compact binary
+// encoding of the vector clock to w and returns the number of bytes written.
+func (vc VClock) WriteTo(w io.Writer) (int64, error) {
+	b, err := vc.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// ReadFrom implements the io.ReaderFrom interface. It reads exactly one vector
+// clock in the compact binary encoding from r, so several clocks written with
+// WriteTo can be read back from the same stream one after another. It returns
+// io.EOF if r is exhausted before the first byte of the clock. The callee is
+// replaced by the decoded clock.
+func (vc *VClock) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+	if br, ok := r.(io.ByteReader); ok {
+		cr.br = br
+	}
+
+	clock, err := readBinary(cr)
+	if err != nil {
+		return cr.n, err
+	}
+
+	*vc = clock
+	return cr.n, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface. It only
+// accepts the compact binary encoding, use FromBytes to also decode clocks
+// that were encoded with the gob package. The callee is replaced by the
+// decoded clock.
+func (vc *VClock) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+
+	clock, err := readBinary(&countingReader{r: r, br: r})
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return errTruncated
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.Len() > 0 {
+		return fmt.Errorf("vclock: %d trailing bytes after binary clock", r.Len())
+	}
+
+	*vc = clock
+	return nil
+}
+
+// readBinary reads a single clock in the compact binary encoding from r.
+func readBinary(r *countingReader) (VClock, error) {
+	magic, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if magic != binaryMagic {
+		return nil, fmt.Errorf("vclock: invalid magic byte 0x%02x in binary clock", magic)
+	}
+
+	version, err := r.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if version != binaryVersion {
+		return nil, fmt.Errorf("vclock: unsupported binary clock version %d", version)
+	}
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	// don't trust the count for the initial allocation, the data may be bogus
+	clock := make(VClock, minUint64(count, 1024))
+	var id strings.Builder
+	prev := ""
+	for i := uint64(0); i < count; i++ {
+		length, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if length > math.MaxInt64 {
+			return nil, fmt.Errorf("vclock: invalid id length %d in binary clock", length)
+		}
+
+		id.Reset()
+		if _, err := io.CopyN(&id, r, int64(length)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		if i > 0 && id.String() <= prev {
+			return nil, fmt.Errorf("vclock: id %q in binary clock is out of order or duplicated", id.String())
+		}
+		prev = id.String()
+
+		ticks, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		clock[prev] = ticks
+	}
+
+	return clock, nil
+}
+
+// isCompactBinary reports whether data starts like a clock in the compact
+// binary encoding rather than in the gob encoding.
+func isCompactBinary(data []byte) bool {
+	return len(data) > 0 && data[0] == binaryMagic
+}
+
+// countingReader counts the bytes read from the underlying reader and never
+// reads further than requested, so that it doesn't consume data that follows
+// the clock in a stream.
+type countingReader struct {
+	r  io.Reader
+	br io.ByteReader
+	n  int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	if c.br != nil {
+		b, err := c.br.ReadByte()
+		if err == nil {
+			c.n++
+		}
+		return b, err
+	}
+
+	var b [1]byte
+	if _, err := io.ReadFull(c, b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// unexpectedEOF turns an io.EOF in the middle of a clock into an
+// io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
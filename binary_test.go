@@ -0,0 +1,181 @@
+package vclock
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = VClock{}
+	_ encoding.BinaryUnmarshaler = &VClock{}
+)
+
+func TestMarshalBinaryFormat(t *testing.T) {
+	n := New()
+	n.Set("b", 300)
+	n.Set("a", 1)
+
+	b, err := n.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{binaryMagic, binaryVersion, 2, 1, 'a', 1, 1, 'b', 0xac, 0x02}
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("binary clock %x not the same as expected %x", b, expected)
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, 10, 1000} {
+		n := genVClock(size)
+
+		var decoded VClock
+		if err := decoded.UnmarshalBinary(n.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+
+		if !n.Compare(decoded, Equal) {
+			failComparison(t, "decoded not the same as encoded enc = %s | dec = %s", n, decoded)
+		}
+	}
+}
+
+// legacyGob is the clock {"a":1, "b":2} as encoded by Bytes before the compact
+// binary encoding was introduced. It must not be generated with the gob
+// package in the tests, since gob uses MarshalBinary for a VClock now.
+const legacyGob = "157f0401010656436c6f636b01ff8000010c010600000aff800002016101016202"
+
+func TestFromBytesGob(t *testing.T) {
+	data, err := hex.DecodeString(legacyGob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := FromBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := VClock{"a": 1, "b": 2}
+	if !expected.Compare(decoded, Equal) {
+		failComparison(t, "decoded not the same as encoded enc = %s | dec = %s", expected, decoded)
+	}
+}
+
+func TestBinarySize(t *testing.T) {
+	for _, n := range []VClock{New(), {"a": 0}, {"a": 127, "b": 128}, {"": 1 << 63}, genVClock(200)} {
+		if b := n.Bytes(); len(b) != n.binarySize() || cap(b) != n.binarySize() {
+			t.Fatalf("binary clock %s has %d bytes, expected %d", n.ReturnVCString(), len(b), n.binarySize())
+		}
+	}
+}
+
+func TestBinarySmallerThanGob(t *testing.T) {
+	n := genVClock(10)
+
+	// encode the plain map, just like earlier versions did
+	b := new(bytes.Buffer)
+	if err := gob.NewEncoder(b).Encode(map[string]uint64(n)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(n.Bytes()) >= b.Len() {
+		t.Fatalf("binary clock has %d bytes, gob clock only %d", len(n.Bytes()), b.Len())
+	}
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":       {},
+		"magic":       {0x00, binaryVersion, 0},
+		"version":     {binaryMagic, 99, 0},
+		"no count":    {binaryMagic, binaryVersion},
+		"count":       {binaryMagic, binaryVersion, 100, 1, 'a', 1},
+		"id length":   {binaryMagic, binaryVersion, 1, 5, 'a', 1},
+		"no ticks":    {binaryMagic, binaryVersion, 1, 1, 'a'},
+		"ticks":       {binaryMagic, binaryVersion, 1, 1, 'a', 0x80},
+		"unsorted":    {binaryMagic, binaryVersion, 2, 1, 'b', 1, 1, 'a', 1},
+		"duplicate":   {binaryMagic, binaryVersion, 2, 1, 'a', 1, 1, 'a', 2},
+		"trailing":    {binaryMagic, binaryVersion, 1, 1, 'a', 1, 0},
+		"varint size": {binaryMagic, binaryVersion, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+	}
+
+	for name, data := range tests {
+		var decoded VClock
+		if err := decoded.UnmarshalBinary(data); err == nil {
+			t.Fatalf("%s: expected error, got %s", name, decoded.ReturnVCString())
+		}
+	}
+}
+
+func TestAppendBytes(t *testing.T) {
+	n := genVClock(10)
+
+	prefix := []byte("prefix")
+	buf := make([]byte, len(prefix), 1024)
+	copy(buf, prefix)
+
+	b, err := n.AppendBytes(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if &b[0] != &buf[0] {
+		t.Fatalf("AppendBytes did not reuse the buffer")
+	}
+
+	if !bytes.Equal(b[:len(prefix)], prefix) || !bytes.Equal(b[len(prefix):], n.Bytes()) {
+		t.Fatalf("AppendBytes returned %x, expected %x followed by %x", b, prefix, n.Bytes())
+	}
+}
+
+func TestWriteToReadFrom(t *testing.T) {
+	clocks := []VClock{genVClock(3), New(), genVClock(100)}
+
+	b := new(bytes.Buffer)
+	for _, c := range clocks {
+		if _, err := c.WriteTo(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.WriteString("rest")
+
+	// OneByteReader hides the io.ByteReader of the buffer
+	r := iotest.OneByteReader(b)
+	for _, c := range clocks {
+		var decoded VClock
+		n, err := decoded.ReadFrom(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(len(c.Bytes())) {
+			t.Fatalf("ReadFrom read %d bytes, expected %d", n, len(c.Bytes()))
+		}
+		if !c.Compare(decoded, Equal) {
+			failComparison(t, "decoded not the same as encoded enc = %s | dec = %s", c, decoded)
+		}
+	}
+
+	if rest, _ := io.ReadAll(r); string(rest) != "rest" {
+		t.Fatalf("ReadFrom consumed data after the clocks, %q left", rest)
+	}
+}
+
+func TestReadFromEOF(t *testing.T) {
+	var decoded VClock
+
+	if _, err := decoded.ReadFrom(bytes.NewReader(nil)); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	b := genVClock(3).Bytes()
+	if _, err := decoded.ReadFrom(bytes.NewReader(b[:len(b)-1])); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package vclock
+
+// Clock is the interface that logical clocks share, so that code such as a
+// messaging layer can work with any of them. The type parameter C is the
+// concrete clock type, which lets Merge and Order take a clock of the same
+// type. VClock implements Clock[VClock] and *Lamport implements
+// Clock[*Lamport], so a function that works with any clock can be written as:
+//
+//	func send[C vclock.Clock[C]](clock C, id string) []byte {
+//		clock.Tick(id)
+//		return clock.Bytes()
+//	}
+type Clock[C any] interface {
+	// Tick records a local event of the process with the given id.
+	Tick(id string)
+	// Merge updates the clock in place with the events known to other.
+	Merge(other C)
+	// Order determines the relationship between the clock and other, see
+	// VClock.Order.
+	Order(other C) Condition
+	// Bytes returns the binary encoding of the clock.
+	Bytes() []byte
+}
+
+var (
+	_ Clock[VClock]   = VClock{}
+	_ Clock[*Lamport] = &Lamport{}
+)
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"git.tu-berlin.de/mcc-fred/vclock"
+)
+
+func runCommand(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		cond vclock.Condition
+	}{
+		{`{"a":1}`, `{"a":1}`, vclock.Equal},
+		{`{"a":2}`, `{"a":1}`, vclock.Ancestor},
+		{`{"a":1}`, `{"a":1, "b":1}`, vclock.Descendant},
+		{`{"a":1}`, `{"b":1}`, vclock.Concurrent},
+	}
+
+	for _, tt := range tests {
+		code, stdout, _ := runCommand(t, "", "compare", tt.a, tt.b)
+		if code != int(tt.cond) || stdout != tt.cond.String()+"\n" {
+			t.Fatalf("compare %s %s: expected %s, got %d %q", tt.a, tt.b, tt.cond, code, stdout)
+		}
+	}
+}
+
+func TestStdin(t *testing.T) {
+	code, stdout, _ := runCommand(t, "{\"a\":1}\n\n{\"b\":2}\n", "compare")
+	if code != int(vclock.Concurrent) || stdout != "Concurrent\n" {
+		t.Fatalf("unexpected result %d %q", code, stdout)
+	}
+
+	// one clock from the arguments, one from stdin
+	code, stdout, _ = runCommand(t, "{\"a\":1, \"b\":1}\n", "compare", `{"a":1}`)
+	if code != int(vclock.Descendant) {
+		t.Fatalf("unexpected result %d %q", code, stdout)
+	}
+
+	code, stdout, _ = runCommand(t, "{\"a\":1}\n{\"a\":3}\n{\"b\":2}\n", "merge")
+	if code != 0 || stdout != "{\"a\":3, \"b\":2}\n" {
+		t.Fatalf("unexpected result %d %q", code, stdout)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	vc := vclock.VClock{"a": 1, "b": 0}
+	encoded := base64.StdEncoding.EncodeToString(vc.Bytes())
+
+	tests := []struct {
+		args   []string
+		stdout string
+	}{
+		{[]string{"merge", `{"a":1}`, `{"a":2, "b":1}`, `{"c":1}`}, "{\"a\":2, \"b\":1, \"c\":1}\n"},
+		{[]string{"tick", "b", `{"a":1}`}, "{\"a\":1, \"b\":1}\n"},
+		{[]string{"normalize", `{"a":1, "b":0}`}, "{\"a\":1}\n"},
+		{[]string{"encode", `{"a":1, "b":0}`}, encoded + "\n"},
+		{[]string{"decode", encoded}, "{\"a\":1, \"b\":0}\n"},
+		{[]string{"decode", strings.TrimRight(encoded, "=")}, "{\"a\":1, \"b\":0}\n"},
+		{[]string{"compare", encoded, `{"a":1, "b":0}`}, "Equal\n"},
+	}
+
+	for _, tt := range tests {
+		_, stdout, stderr := runCommand(t, "", tt.args...)
+		if stdout != tt.stdout {
+			t.Fatalf("%v: expected %q, got %q (%s)", tt.args, tt.stdout, stdout, stderr)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		stdin string
+		args  []string
+		code  int
+	}{
+		{"", nil, exitUsage},
+		{"", []string{"frobnicate"}, exitUsage},
+		{"", []string{"compare", `{"a":1}`}, exitUsage},
+		{"", []string{"compare", `{"a":1}`, `{"a":1}`, `{"a":1}`}, exitUsage},
+		{"", []string{"tick"}, exitUsage},
+		{"", []string{"decode", `{"a":}`}, exitDataErr},
+		{"", []string{"decode", "not base64!"}, exitDataErr},
+		{"", []string{"decode", "AAAA"}, exitDataErr},
+		{"{\"a\":-1}\n", []string{"normalize"}, exitDataErr},
+	}
+
+	for _, tt := range tests {
+		code, _, stderr := runCommand(t, tt.stdin, tt.args...)
+		if code != tt.code || stderr == "" {
+			t.Fatalf("%v: expected exit code %d, got %d (%q)", tt.args, tt.code, code, stderr)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package vclock
+
+// CounterRange is an inclusive range of clock values of a single process.
+type CounterRange struct {
+	From uint64
+	To   uint64
+}
+
+// Deliverable reports whether a broadcast message from sender with the clock
+// msg can be delivered to a process whose clock of delivered messages is vc.
+// That is the case if msg is the next message of sender, msg[sender] ==
+// vc[sender]+1, and every message that sender had delivered before sending it
+// has been delivered as well, msg[k] <= vc[k] for every other id k.
+//
+// Like CausalQueue, Deliverable assumes that clocks count broadcasts only.
+func (vc VClock) Deliverable(sender string, msg VClock) bool {
+	if msg[sender] != vc[sender]+1 {
+		return false
+	}
+	for id, ticks := range msg {
+		if id != sender && ticks > vc[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// MissingDeps returns, for every process id, the range of clock values of the
+// messages that must be delivered before the message from sender with the
+// clock msg is Deliverable. It returns an empty map if the message is
+// deliverable, or if it has already been delivered, msg[sender] <= vc[sender].
+func (vc VClock) MissingDeps(sender string, msg VClock) map[string]CounterRange {
+	missing := map[string]CounterRange{}
+	if msg[sender] <= vc[sender] {
+		return missing
+	}
+
+	for id, ticks := range msg {
+		if id == sender {
+			// the message itself is not a dependency
+			ticks--
+		}
+		if ticks > vc[id] {
+			missing[id] = CounterRange{From: vc[id] + 1, To: ticks}
+		}
+	}
+	return missing
+}
@@ -0,0 +1,41 @@
+package vclock
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeliverable(t *testing.T) {
+	local := VClock{"a": 2, "b": 1}
+
+	tests := []struct {
+		name    string
+		sender  string
+		msg     VClock
+		want    bool
+		missing map[string]CounterRange
+	}{
+		{"next message", "a", VClock{"a": 3}, true, map[string]CounterRange{}},
+		{"next message with known deps", "a", VClock{"a": 3, "b": 1}, true, map[string]CounterRange{}},
+		{"first message of new sender", "c", VClock{"a": 1, "c": 1}, true, map[string]CounterRange{}},
+		{"already delivered", "a", VClock{"a": 2}, false, map[string]CounterRange{}},
+		{"already delivered with unknown deps", "a", VClock{"a": 1, "b": 5}, false, map[string]CounterRange{}},
+		{"gap at sender", "a", VClock{"a": 5}, false, map[string]CounterRange{"a": {From: 3, To: 4}}},
+		{"missing dep", "a", VClock{"a": 3, "b": 2}, false, map[string]CounterRange{"b": {From: 2, To: 2}}},
+		{"missing deps of new sender", "c", VClock{"b": 3, "c": 2, "d": 1}, false, map[string]CounterRange{
+			"b": {From: 2, To: 3},
+			"c": {From: 1, To: 1},
+			"d": {From: 1, To: 1},
+		}},
+		{"sender not in clock", "c", VClock{"a": 1}, false, map[string]CounterRange{}},
+	}
+
+	for _, tt := range tests {
+		if got := local.Deliverable(tt.sender, tt.msg); got != tt.want {
+			t.Errorf("%s: Deliverable = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := local.MissingDeps(tt.sender, tt.msg); !reflect.DeepEqual(got, tt.missing) {
+			t.Errorf("%s: MissingDeps = %v, want %v", tt.name, got, tt.missing)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package vclock
+
+import "sync"
+
+// IDTable interns process ids: it maps every process id to a small integer,
+// its index, so that clocks can store their entries in a slice instead of
+// repeating the ids in every clock, see DenseClock. Indices are assigned in
+// the order in which ids are interned, starting at 0, and never change.
+//
+// An IDTable is safe for concurrent use by multiple goroutines.
+type IDTable struct {
+	mu    sync.RWMutex
+	index map[string]int
+	names []string
+}
+
+// NewIDTable returns a new, empty table.
+func NewIDTable() *IDTable {
+	return &IDTable{index: map[string]int{}}
+}
+
+// Intern returns the index of the given process id, adding the id to the
+// table if it is not part of it yet.
+func (t *IDTable) Intern(id string) int {
+	if i, ok := t.Lookup(id); ok {
+		return i
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// another goroutine may have added the id in the meantime
+	if i, ok := t.index[id]; ok {
+		return i
+	}
+	i := len(t.names)
+	t.index[id] = i
+	t.names = append(t.names, id)
+	return i
+}
+
+// Lookup returns the index of the given process id or false if the id is not
+// part of the table.
+func (t *IDTable) Lookup(id string) (int, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	i, ok := t.index[id]
+	return i, ok
+}
+
+// Name returns the process id with the given index. It panics if the index is
+// not part of the table.
+func (t *IDTable) Name(i int) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.names[i]
+}
+
+// Len returns the number of process ids in the table.
+func (t *IDTable) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return len(t.names)
+}
+
+// DenseClock is a vector clock that stores the clock value of every process
+// at the index of its id in an IDTable. All clocks that are compared or merged
+// must use the same table. Process ids that are missing from the clock,
+// including indices beyond its length, have a clock value of 0, so unlike a
+// VClock, a DenseClock does not distinguish between missing entries and
+// entries with a clock value of 0.
+//
+// The zero value is an empty clock ready to use. Just like VClock, a
+// DenseClock is not safe for concurrent use.
+type DenseClock []uint64
+
+// NewDenseClock returns the given vector clock as a DenseClock, interning its
+// process ids in the given table.
+func NewDenseClock(t *IDTable, vc VClock) DenseClock {
+	var c DenseClock
+	for _, id := range vc.sortedIDs() {
+		c.Set(t.Intern(id), vc[id])
+	}
+	return c
+}
+
+// VClock returns the clock as a new VClock, using the given table to look up
+// the process ids. Entries with a clock value of 0 are omitted.
+func (c DenseClock) VClock(t *IDTable) VClock {
+	vc := New()
+	for i, ticks := range c {
+		if ticks > 0 {
+			vc[t.Name(i)] = ticks
+		}
+	}
+	return vc
+}
+
+// Copy returns a copy of the clock.
+func (c DenseClock) Copy() DenseClock {
+	return append(DenseClock(nil), c...)
+}
+
+// Get returns the clock value of the process with the given index.
+func (c DenseClock) Get(i int) uint64 {
+	if i < len(c) {
+		return c[i]
+	}
+	return 0
+}
+
+// Set sets the clock value of the process with the given index to the given
+// value, growing the clock if needed.
+func (c *DenseClock) Set(i int, ticks uint64) {
+	c.grow(i + 1)
+	(*c)[i] = ticks
+}
+
+// Tick increments the clock value of the process with the given index by 1,
+// growing the clock if needed.
+func (c *DenseClock) Tick(i int) {
+	c.grow(i + 1)
+	(*c)[i]++
+}
+
+// Merge takes the maximum of all clock values in other and updates the values
+// of the callee, see VClock.Merge.
+func (c *DenseClock) Merge(other DenseClock) {
+	c.grow(len(other))
+	for i, ticks := range other {
+		if (*c)[i] < ticks {
+			(*c)[i] = ticks
+		}
+	}
+}
+
+// Order determines the relationship between two clocks, see VClock.Order.
+// Since a DenseClock does not distinguish missing entries from entries with a
+// clock value of 0, the result is the same as the one of VClock.OrderNormalized
+// for the corresponding vector clocks.
+func (c DenseClock) Order(other DenseClock) Condition {
+	cBigger := false
+	otherBigger := false
+
+	n := len(c)
+	if len(other) > n {
+		n = len(other)
+	}
+	for i := 0; i < n && !(cBigger && otherBigger); i++ {
+		ticks, otherTicks := c.Get(i), other.Get(i)
+		if ticks > otherTicks {
+			cBigger = true
+		} else if ticks < otherTicks {
+			otherBigger = true
+		}
+	}
+
+	return condition(cBigger, otherBigger)
+}
+
+// Compare takes another clock and determines if it is Equal, an Ancestor,
+// Descendant, or Concurrent with the callee according to Order. The condition
+// may be ORed, see VClock.Compare.
+func (c DenseClock) Compare(other DenseClock, cond Condition) bool {
+	return c.Order(other)&cond != 0
+}
+
+// grow makes sure the clock has at least n entries.
+func (c *DenseClock) grow(n int) {
+	if n > len(*c) {
+		*c = append(*c, make(DenseClock, n-len(*c))...)
+	}
+}
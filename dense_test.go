@@ -0,0 +1,116 @@
+package vclock
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestIDTable(t *testing.T) {
+	table := NewIDTable()
+
+	if table.Intern("a") != 0 || table.Intern("b") != 1 || table.Intern("a") != 0 {
+		t.Fatalf("unexpected indices")
+	}
+	if i, ok := table.Lookup("b"); !ok || i != 1 {
+		t.Fatalf("unexpected lookup %d %v", i, ok)
+	}
+	if _, ok := table.Lookup("c"); ok {
+		t.Fatalf("lookup of unknown id succeeded")
+	}
+	if table.Name(1) != "b" || table.Len() != 2 {
+		t.Fatalf("unexpected table contents")
+	}
+}
+
+func TestIDTableConcurrent(t *testing.T) {
+	table := NewIDTable()
+
+	var wg sync.WaitGroup
+	indices := make([][]int, 8)
+	for g := range indices {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				indices[g] = append(indices[g], table.Intern(strconv.Itoa(i)))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if table.Len() != 100 {
+		t.Fatalf("expected 100 ids, got %d", table.Len())
+	}
+	for g := range indices {
+		for i, index := range indices[g] {
+			if table.Name(index) != strconv.Itoa(i) {
+				t.Fatalf("id %d interned as %d, which is %s", i, index, table.Name(index))
+			}
+		}
+	}
+}
+
+func TestDenseClockAgreesWithVClock(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	table := NewIDTable()
+
+	for i := 0; i < 2000; i++ {
+		a, b := randomVClock(r, 8), randomVClock(r, 8)
+		da, db := NewDenseClock(table, a), NewDenseClock(table, b)
+
+		if da.Order(db) != a.OrderNormalized(b) || !da.Compare(db, a.OrderNormalized(b)) {
+			failComparison(t, "Order does not agree with OrderNormalized: a = %s | b = %s", a, b)
+		}
+
+		id := strconv.Itoa(r.Intn(10))
+		da.Tick(table.Intern(id))
+		a.Tick(id)
+		da.Merge(db)
+		a.Merge(b)
+
+		a.Normalize()
+		if vc := da.VClock(table); !reflect.DeepEqual(vc, a) {
+			t.Fatalf("dense clock %s, expected %s", vc.ReturnVCString(), a.ReturnVCString())
+		}
+	}
+}
+
+func TestDenseClockGrow(t *testing.T) {
+	var c DenseClock
+	c.Tick(3)
+	if !reflect.DeepEqual(c, DenseClock{0, 0, 0, 1}) || c.Get(10) != 0 {
+		t.Fatalf("unexpected clock %v", c)
+	}
+
+	// entries beyond the length of a shortened clock do not come back
+	short := c[:1]
+	short.Set(2, 5)
+	if !reflect.DeepEqual(short, DenseClock{0, 0, 5}) {
+		t.Fatalf("unexpected clock %v", short)
+	}
+
+	cp := c.Copy()
+	cp.Tick(0)
+	if c.Get(0) != 0 || (DenseClock{}).Order(DenseClock{0, 0}) != Equal {
+		t.Fatalf("copy not independent or trailing zeros not ignored")
+	}
+}
+
+func BenchmarkDenseClockOrder(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			table := NewIDTable()
+			c1 := NewDenseClock(table, genVClock(size))
+			c2 := NewDenseClock(table, genVClock(size))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c1.Order(c2)
+			}
+		})
+	}
+}
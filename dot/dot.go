@@ -0,0 +1,98 @@
+// Package dot exports causal histories of vector clocks as Graphviz DOT graphs
+// (https://graphviz.org/doc/info/lang.html).
+//
+// Every process gets its own lane, events are connected by the edges of the
+// transitive reduction of the happens-before relation, and pairs of concurrent
+// events are connected by dashed red lines. Render the output with, e.g.:
+//
+//	dot -Tsvg history.dot > history.svg
+package dot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"git.tu-berlin.de/mcc-fred/vclock"
+	"git.tu-berlin.de/mcc-fred/vclock/internal/hb"
+)
+
+// Event is a labelled event of a causal history.
+type Event struct {
+	// Label describes the event, e.g., the write it stands for.
+	Label string
+	// Process is the id of the process the event happened at, it selects the
+	// lane of the event.
+	Process string
+	// Clock is the vector clock of the event.
+	Clock vclock.VClock
+}
+
+// Options configure the output of Write.
+type Options struct {
+	// HideConcurrent omits the lines between concurrent events.
+	HideConcurrent bool
+}
+
+// Write writes the causal history of the given events to w as a DOT graph.
+// Events keep the order in which they are given within their lane.
+func Write(w io.Writer, events []Event, opts Options) error {
+	bw := bufio.NewWriter(w)
+
+	lanes := map[string][]int{}
+	for i, e := range events {
+		lanes[e.Process] = append(lanes[e.Process], i)
+	}
+	processes := make([]string, 0, len(lanes))
+	for p := range lanes {
+		processes = append(processes, p)
+	}
+	sort.Strings(processes)
+
+	fmt.Fprintln(bw, "digraph {")
+	fmt.Fprintln(bw, "\trankdir=LR;")
+	fmt.Fprintln(bw, "\tnode [shape=box];")
+
+	for n, p := range processes {
+		fmt.Fprintf(bw, "\tsubgraph cluster_%d {\n", n)
+		fmt.Fprintf(bw, "\t\tlabel=%s;\n", quote(p))
+		for _, i := range lanes[p] {
+			e := events[i]
+			fmt.Fprintf(bw, "\t\te%d [label=%s];\n", i, quote(e.Label+"\n"+e.Clock.ReturnVCString()))
+		}
+		fmt.Fprintln(bw, "\t}")
+	}
+
+	clocks := make([]vclock.VClock, len(events))
+	for i, e := range events {
+		clocks[i] = e.Clock
+	}
+	for to, preds := range hb.Reduce(clocks) {
+		for _, from := range preds {
+			fmt.Fprintf(bw, "\te%d -> e%d;\n", from, to)
+		}
+	}
+
+	if !opts.HideConcurrent {
+		for i := range clocks {
+			for j := i + 1; j < len(clocks); j++ {
+				if clocks[i].Order(clocks[j]) == vclock.Concurrent {
+					fmt.Fprintf(bw, "\te%d -> e%d [dir=none, style=dashed, color=red, constraint=false];\n", i, j)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// quoter escapes the characters that are special in DOT strings.
+var quoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote returns s as a DOT string.
+func quote(s string) string {
+	return `"` + quoter.Replace(s) + `"`
+}
@@ -0,0 +1,68 @@
+package dot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"git.tu-berlin.de/mcc-fred/vclock"
+)
+
+func history() []Event {
+	return []Event{
+		{Label: "put x=1", Process: "a", Clock: vclock.VClock{"a": 1}},
+		{Label: "put x=2", Process: "b", Clock: vclock.VClock{"b": 1}},
+		{Label: "get \"x\"", Process: "a", Clock: vclock.VClock{"a": 2, "b": 1}},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, history(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `digraph {
+	rankdir=LR;
+	node [shape=box];
+	subgraph cluster_0 {
+		label="a";
+		e0 [label="put x=1\n{\"a\":1}"];
+		e2 [label="get \"x\"\n{\"a\":2, \"b\":1}"];
+	}
+	subgraph cluster_1 {
+		label="b";
+		e1 [label="put x=2\n{\"b\":1}"];
+	}
+	e0 -> e2;
+	e1 -> e2;
+	e0 -> e1 [dir=none, style=dashed, color=red, constraint=false];
+}
+`
+	if buf.String() != expected {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestWriteHideConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, history(), Options{HideConcurrent: true}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "dashed") {
+		t.Fatalf("concurrent events not hidden:\n%s", buf.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriteError(t *testing.T) {
+	if err := Write(failingWriter{}, history(), Options{}); err == nil {
+		t.Fatalf("expected error for failing writer")
+	}
+}
@@ -0,0 +1,171 @@
+package vclock
+
+// Dot identifies a single event, the Counter-th event of the process with the
+// given ID. Counters start at 1, so a Dot with a Counter of 0 identifies no
+// event at all.
+type Dot struct {
+	ID      string
+	Counter uint64
+}
+
+// DVV is a dotted version vector as described by Preguiça et al. in "Dotted
+// Version Vectors: Logical Clocks for Optimistic Replication"
+// (https://arxiv.org/abs/1011.5808). It is a vector clock that describes the
+// causal past of an event plus an optional dot that identifies the event
+// itself. Unlike plain vector clocks, dotted version vectors can tell apart
+// concurrent writes that the same server coordinates on behalf of different
+// clients.
+//
+// A DVV represents the set of events that consists of the first Clock[id]
+// events of every process id plus the event identified by Dot, if it has one.
+// In consequence, an entry with a clock value of 0 is the same as a missing
+// entry.
+type DVV struct {
+	// Dot is the event that the DVV stands for, the zero Dot means that the
+	// DVV has no dot.
+	Dot Dot
+	// Clock is the causal past of the event.
+	Clock VClock
+}
+
+// NewDVV returns a DVV without a dot that describes the given causal past.
+func NewDVV(clock VClock) DVV {
+	return DVV{Clock: clock.Copy()}
+}
+
+// HasDot reports whether the DVV has a dot.
+func (d DVV) HasDot() bool {
+	return d.Dot.Counter > 0
+}
+
+// Copy returns a deep copy of a DVV.
+func (d DVV) Copy() DVV {
+	return DVV{Dot: d.Dot, Clock: d.Clock.Copy()}
+}
+
+// Contains reports whether the event identified by dot is part of the DVV.
+func (d DVV) Contains(dot Dot) bool {
+	if dot.Counter == 0 {
+		return false
+	}
+	return dot.Counter <= d.Clock[dot.ID] || dot == d.Dot
+}
+
+// Join returns a vector clock that covers all events of the DVV. It is the
+// clock with the dot folded into its entry for the dot's process id.
+func (d DVV) Join() VClock {
+	ceiling := d.Clock.Copy()
+	if d.HasDot() && ceiling[d.Dot.ID] < d.Dot.Counter {
+		ceiling[d.Dot.ID] = d.Dot.Counter
+	}
+	return ceiling
+}
+
+// Order determines the relationship between two DVVs by comparing the sets of
+// events they represent. Just like VClock.Order, it returns Ancestor if other
+// is an ancestor of d, Descendant if other is a descendant of d, Equal if they
+// represent the same events, and Concurrent otherwise.
+func (d DVV) Order(other DVV) Condition {
+	dBigger := !d.leq(other)
+	otherBigger := !other.leq(d)
+	return condition(dBigger, otherBigger)
+}
+
+// Compare takes another DVV and determines if it is Equal, an Ancestor,
+// Descendant, or Concurrent with the callee. The condition may be ORed,
+// see VClock.Compare.
+func (d DVV) Compare(other DVV, cond Condition) bool {
+	return d.Order(other)&cond != 0
+}
+
+// leq reports whether all events of d are also events of other.
+func (d DVV) leq(other DVV) bool {
+	for id, ticks := range d.Clock {
+		if ticks <= other.Clock[id] {
+			continue
+		}
+
+		// the dot of other may fill in exactly the one missing event
+		if other.Dot.ID == id && other.Dot.Counter == ticks && other.Clock[id] == ticks-1 {
+			continue
+		}
+
+		return false
+	}
+
+	return !d.HasDot() || other.Contains(d.Dot)
+}
+
+// UpdateDVV creates the DVV for a new write that the server with the given id
+// coordinates. siblings are the DVVs that the server currently stores for the
+// key and context is the causal context the client read before writing. The
+// new DVV gets the next dot of the server and the client's context as its
+// causal past. Combine it with the existing siblings using SyncDVV:
+//
+//	write := vclock.UpdateDVV(siblings, context, "server")
+//	siblings = vclock.SyncDVV(siblings, []vclock.DVV{write})
+func UpdateDVV(siblings []DVV, context VClock, id string) DVV {
+	counter := context[id]
+	if c := JoinDVV(siblings)[id]; c > counter {
+		counter = c
+	}
+
+	return DVV{
+		Dot:   Dot{ID: id, Counter: counter + 1},
+		Clock: context.Copy(),
+	}
+}
+
+// SyncDVV merges two sets of sibling DVVs. The result contains every DVV of a
+// and b that is not an ancestor of a DVV in the other set, DVVs that are
+// equal appear only once. The DVVs of a and b are not copied.
+func SyncDVV(a, b []DVV) []DVV {
+	var synced []DVV
+
+	for _, x := range a {
+		if !obsoleted(x, b) {
+			synced = append(synced, x)
+		}
+	}
+
+	for _, x := range b {
+		if obsoleted(x, a) {
+			continue
+		}
+
+		duplicate := false
+		for _, y := range a {
+			if x.Order(y) == Equal {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			synced = append(synced, x)
+		}
+	}
+
+	return synced
+}
+
+// obsoleted reports whether any DVV in set is a descendant of x.
+func obsoleted(x DVV, set []DVV) bool {
+	for _, y := range set {
+		if x.Order(y) == Descendant {
+			return true
+		}
+	}
+	return false
+}
+
+// JoinDVV returns a vector clock that covers all events of the given DVVs.
+// It is the causal context that a client should receive when it reads all
+// siblings of a key.
+func JoinDVV(dvvs []DVV) VClock {
+	ceiling := New()
+	for _, d := range dvvs {
+		ceiling.Merge(d.Join())
+	}
+	return ceiling
+}
@@ -0,0 +1,124 @@
+package vclock
+
+import "testing"
+
+func failDVVComparison(t *testing.T, failMessage string, d1, d2 DVV) {
+	t.Fatalf(failMessage, d1.Dot, d1.Clock.ReturnVCString(), d2.Dot, d2.Clock.ReturnVCString())
+}
+
+func TestDVVConcurrentWritesSameServer(t *testing.T) {
+	var siblings []DVV
+
+	// two clients write without having read anything
+	w1 := UpdateDVV(siblings, New(), "A")
+	siblings = SyncDVV(siblings, []DVV{w1})
+	w2 := UpdateDVV(siblings, New(), "A")
+	siblings = SyncDVV(siblings, []DVV{w2})
+
+	if w1.Dot != (Dot{"A", 1}) || w2.Dot != (Dot{"A", 2}) {
+		t.Fatalf("unexpected dots %v and %v", w1.Dot, w2.Dot)
+	}
+	if w1.Order(w2) != Concurrent {
+		failDVVComparison(t, "DVVs not defined as Concurrent: d1 = %v %s | d2 = %v %s", w1, w2)
+	}
+	if len(siblings) != 2 {
+		t.Fatalf("expected 2 siblings, got %d", len(siblings))
+	}
+
+	// a client that read both siblings overwrites them
+	context := JoinDVV(siblings)
+	if context.ReturnVCString() != "{\"A\":2}" {
+		t.Fatalf("unexpected context %s", context.ReturnVCString())
+	}
+
+	w3 := UpdateDVV(siblings, context, "A")
+	siblings = SyncDVV(siblings, []DVV{w3})
+
+	if w3.Dot != (Dot{"A", 3}) {
+		t.Fatalf("unexpected dot %v", w3.Dot)
+	}
+	if len(siblings) != 1 || siblings[0].Dot != w3.Dot {
+		t.Fatalf("expected only the last write to survive, got %v", siblings)
+	}
+	if w1.Order(w3) != Descendant || w3.Order(w2) != Ancestor {
+		failDVVComparison(t, "DVVs not ordered: d1 = %v %s | d2 = %v %s", w1, w3)
+	}
+}
+
+func TestDVVOrder(t *testing.T) {
+	c := New()
+	c.Set("A", 1)
+	c.Set("B", 2)
+
+	d1 := DVV{Dot: Dot{"A", 2}, Clock: c}
+	d2 := NewDVV(d1.Join())
+	d3 := DVV{Dot: Dot{"A", 2}, Clock: c.Copy()}
+	d4 := DVV{Dot: Dot{"B", 3}, Clock: c.Copy()}
+
+	if d1.Order(d2) != Equal || !d1.Compare(d2, Equal) {
+		failDVVComparison(t, "DVVs not defined as Equal: d1 = %v %s | d2 = %v %s", d1, d2)
+	}
+	if d1.Order(d3) != Equal {
+		failDVVComparison(t, "DVVs not defined as Equal: d1 = %v %s | d2 = %v %s", d1, d3)
+	}
+	if d1.Order(d4) != Concurrent {
+		failDVVComparison(t, "DVVs not defined as Concurrent: d1 = %v %s | d2 = %v %s", d1, d4)
+	}
+
+	d5 := NewDVV(c)
+	if d5.Order(d1) != Descendant || d1.Order(d5) != Ancestor {
+		failDVVComparison(t, "DVVs not ordered: d1 = %v %s | d2 = %v %s", d5, d1)
+	}
+
+	// a zero entry is the same as a missing one
+	z := c.Copy()
+	z.Set("C", 0)
+	if NewDVV(z).Order(d5) != Equal {
+		failDVVComparison(t, "DVVs not defined as Equal: d1 = %v %s | d2 = %v %s", NewDVV(z), d5)
+	}
+}
+
+func TestDVVJoin(t *testing.T) {
+	c := New()
+	c.Set("A", 1)
+	c.Set("B", 2)
+
+	d := DVV{Dot: Dot{"A", 5}, Clock: c}
+	j := d.Join()
+
+	if j.ReturnVCString() != "{\"A\":5, \"B\":2}" {
+		t.Fatalf("unexpected join %s", j.ReturnVCString())
+	}
+	if c.ReturnVCString() != "{\"A\":1, \"B\":2}" {
+		t.Fatalf("Join modified the clock %s", c.ReturnVCString())
+	}
+	if !d.Contains(Dot{"A", 5}) || d.Contains(Dot{"A", 3}) || !d.Contains(Dot{"B", 1}) || d.Contains(Dot{"B", 0}) {
+		t.Fatalf("unexpected events in %v %s", d.Dot, d.Clock.ReturnVCString())
+	}
+}
+
+func TestSyncDVV(t *testing.T) {
+	a := []DVV{
+		{Dot: Dot{"A", 1}, Clock: New()},
+		{Dot: Dot{"B", 1}, Clock: New()},
+	}
+	b := []DVV{
+		{Dot: Dot{"A", 2}, Clock: VClock{"A": 1}},
+		{Dot: Dot{"B", 1}, Clock: New()},
+	}
+
+	for _, synced := range [][]DVV{SyncDVV(a, b), SyncDVV(b, a)} {
+		if len(synced) != 2 {
+			t.Fatalf("expected 2 siblings, got %v", synced)
+		}
+		for _, d := range synced {
+			if d.Dot == (Dot{"A", 1}) {
+				t.Fatalf("obsolete sibling survived: %v", synced)
+			}
+		}
+	}
+
+	if synced := SyncDVV(a, a); len(synced) != len(a) {
+		t.Fatalf("sync is not idempotent: %v", synced)
+	}
+}
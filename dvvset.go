@@ -0,0 +1,455 @@
+package vclock
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// DVVSet is a dotted version vector set in the style of Riak's dvvset, see
+// Almeida et al., "Scalable and Accurate Causality Tracking for Eventually
+// Consistent Stores" (https://doi.org/10.1007/978-3-662-43352-2_6). It holds all
+// concurrent sibling values of a key together with their causal context in a
+// single compact structure.
+//
+// For every process id, a DVVSet stores a counter n and a list of values that
+// belong to the last events of that process, i.e., to the counters n, n-1, and
+// so on. Values that are not tied to an event yet, e.g., values that a client
+// sends along with its context, are anonymous.
+//
+// DVVSet values are immutable, all operations return a new DVVSet. The zero
+// value is an empty set.
+//
+// A DVVSet can be encoded as JSON if its values can. The binary encoding only
+// supports values of type string and values that implement
+// encoding.BinaryMarshaler and whose pointers implement
+// encoding.BinaryUnmarshaler. MarshalBinary and UnmarshalBinary return an
+// error for all other value types.
+type DVVSet[T comparable] struct {
+	entries   []dvvSetEntry[T]
+	anonymous []T
+}
+
+// dvvSetEntry holds the counter of a process id and the values of its last
+// events, newest first.
+type dvvSetEntry[T comparable] struct {
+	id      string
+	counter uint64
+	values  []T
+}
+
+// NewDVVSet returns a DVVSet with the given causal context and anonymous
+// values. context may be nil if the values have no causal past. Use it on the
+// client side to wrap a new value and the context that was read before
+// writing, and pass the result to Update on the server.
+func NewDVVSet[T comparable](context VClock, values ...T) DVVSet[T] {
+	s := DVVSet[T]{anonymous: append([]T(nil), values...)}
+	for _, id := range context.sortedIDs() {
+		if context[id] > 0 {
+			s.entries = append(s.entries, dvvSetEntry[T]{id: id, counter: context[id]})
+		}
+	}
+	return s
+}
+
+// Sync merges two DVVSets. Values that are part of the causal past of the
+// other set are discarded, all other values are kept.
+func (s DVVSet[T]) Sync(other DVVSet[T]) DVVSet[T] {
+	var anonymous []T
+	switch {
+	case s.less(other):
+		anonymous = other.anonymous
+	case other.less(s):
+		anonymous = s.anonymous
+	default:
+		anonymous = append([]T(nil), s.anonymous...)
+		for _, v := range other.anonymous {
+			if !containsValue(anonymous, v) {
+				anonymous = append(anonymous, v)
+			}
+		}
+	}
+
+	var entries []dvvSetEntry[T]
+	i, j := 0, 0
+	for i < len(s.entries) || j < len(other.entries) {
+		switch {
+		case j == len(other.entries) || (i < len(s.entries) && s.entries[i].id < other.entries[j].id):
+			entries = append(entries, s.entries[i])
+			i++
+		case i == len(s.entries) || other.entries[j].id < s.entries[i].id:
+			entries = append(entries, other.entries[j])
+			j++
+		default:
+			entries = append(entries, mergeEntries(s.entries[i], other.entries[j]))
+			i++
+			j++
+		}
+	}
+
+	return DVVSet[T]{entries: entries, anonymous: anonymous}
+}
+
+// mergeEntries merges two entries of the same process id. The merged entry
+// has the higher counter and keeps only the values that neither entry has
+// already discarded.
+func mergeEntries[T comparable](a, b dvvSetEntry[T]) dvvSetEntry[T] {
+	if a.counter < b.counter {
+		a, b = b, a
+	}
+
+	// b knows the events up to b.counter, and it has discarded the values of
+	// all events before b.counter-len(b.values)+1
+	if a.counter-uint64(len(a.values)) >= b.counter-uint64(len(b.values)) {
+		return a
+	}
+
+	keep := a.counter - b.counter + uint64(len(b.values))
+	return dvvSetEntry[T]{id: a.id, counter: a.counter, values: a.values[:keep:keep]}
+}
+
+// less reports whether the causal history of s is strictly contained in the
+// causal history of other.
+func (s DVVSet[T]) less(other DVVSet[T]) bool {
+	strict := false
+	i, j := 0, 0
+	for i < len(s.entries) {
+		if j == len(other.entries) || s.entries[i].id < other.entries[j].id {
+			// s has an entry that other does not have
+			return false
+		}
+
+		if other.entries[j].id < s.entries[i].id {
+			strict = true
+			j++
+			continue
+		}
+
+		if s.entries[i].counter > other.entries[j].counter {
+			return false
+		}
+		if s.entries[i].counter < other.entries[j].counter {
+			strict = true
+		}
+		i++
+		j++
+	}
+	return strict || j < len(other.entries)
+}
+
+// Update registers the anonymous values of s as new events of the process
+// with the given id and merges them into server. s is usually created by a
+// client with NewDVVSet from its causal context and the value to write,
+// server is the DVVSet the server currently stores for the key. Values of
+// server that are part of the context are discarded.
+func (s DVVSet[T]) Update(id string, server DVVSet[T]) DVVSet[T] {
+	synced := DVVSet[T]{entries: s.entries}.Sync(server)
+
+	for _, v := range s.anonymous {
+		synced.entries = synced.event(id, v)
+	}
+	return synced
+}
+
+// event returns the entries of s with a new event with the given value for the
+// process id.
+func (s DVVSet[T]) event(id string, v T) []dvvSetEntry[T] {
+	i := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].id >= id })
+
+	entries := make([]dvvSetEntry[T], 0, len(s.entries)+1)
+	entries = append(entries, s.entries[:i]...)
+	if i < len(s.entries) && s.entries[i].id == id {
+		e := s.entries[i]
+		values := append([]T{v}, e.values...)
+		entries = append(entries, dvvSetEntry[T]{id: id, counter: e.counter + 1, values: values})
+		i++
+	} else {
+		entries = append(entries, dvvSetEntry[T]{id: id, counter: 1, values: []T{v}})
+	}
+	return append(entries, s.entries[i:]...)
+}
+
+// Join returns the causal context of the DVVSet as a vector clock. Clients
+// should read it together with the values and send it back when they write.
+func (s DVVSet[T]) Join() VClock {
+	vc := make(VClock, len(s.entries))
+	for _, e := range s.entries {
+		vc[e.id] = e.counter
+	}
+	return vc
+}
+
+// Values returns all sibling values of the DVVSet, including the anonymous
+// ones.
+func (s DVVSet[T]) Values() []T {
+	var values []T
+	for _, e := range s.entries {
+		values = append(values, e.values...)
+	}
+	return append(values, s.anonymous...)
+}
+
+// Size returns the number of sibling values of the DVVSet.
+func (s DVVSet[T]) Size() int {
+	size := len(s.anonymous)
+	for _, e := range s.entries {
+		size += len(e.values)
+	}
+	return size
+}
+
+// Reconcile resolves the siblings of the DVVSet with the given function. The
+// result holds the single value returned by f as an anonymous value, with the
+// causal context of all siblings. If the DVVSet has no values, Reconcile
+// returns it unchanged without calling f.
+func (s DVVSet[T]) Reconcile(f func(values []T) T) DVVSet[T] {
+	if s.Size() == 0 {
+		return s
+	}
+	return NewDVVSet(s.Join(), f(s.Values()))
+}
+
+// LWW resolves the siblings of the DVVSet by keeping only the last written
+// value according to less, which reports whether a was written before b. It is
+// a shortcut for Reconcile with a function that picks the maximum value. Like
+// Reconcile, it returns a DVVSet without values unchanged.
+func (s DVVSet[T]) LWW(less func(a, b T) bool) DVVSet[T] {
+	return s.Reconcile(func(values []T) T {
+		var last T
+		for i, v := range values {
+			if i == 0 || less(last, v) {
+				last = v
+			}
+		}
+		return last
+	})
+}
+
+func containsValue[T comparable](values []T, v T) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// dvvSetJSON is the JSON representation of a DVVSet.
+type dvvSetJSON[T comparable] struct {
+	Entries   []dvvSetEntryJSON[T] `json:"entries"`
+	Anonymous []T                  `json:"anonymous"`
+}
+
+type dvvSetEntryJSON[T comparable] struct {
+	ID      string `json:"id"`
+	Counter uint64 `json:"counter"`
+	Values  []T    `json:"values"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. Values are encoded with
+// the encoding/json package.
+func (s DVVSet[T]) MarshalJSON() ([]byte, error) {
+	j := dvvSetJSON[T]{
+		Entries:   make([]dvvSetEntryJSON[T], 0, len(s.entries)),
+		Anonymous: s.anonymous,
+	}
+	if j.Anonymous == nil {
+		j.Anonymous = []T{}
+	}
+
+	for _, e := range s.entries {
+		values := e.values
+		if values == nil {
+			values = []T{}
+		}
+		j.Entries = append(j.Entries, dvvSetEntryJSON[T]{ID: e.id, Counter: e.counter, Values: values})
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The callee is
+// replaced by the decoded DVVSet.
+func (s *DVVSet[T]) UnmarshalJSON(data []byte) error {
+	var j dvvSetJSON[T]
+	if err := json.Unmarshal(data, &j); err != nil {
+		return fmt.Errorf("vclock: cannot decode JSON DVVSet: %w", err)
+	}
+
+	decoded := DVVSet[T]{anonymous: j.Anonymous}
+	for _, e := range j.Entries {
+		decoded.entries = append(decoded.entries, dvvSetEntry[T]{id: e.ID, counter: e.Counter, values: e.Values})
+	}
+	if err := decoded.validate(); err != nil {
+		return err
+	}
+
+	*s = decoded
+	return nil
+}
+
+// validate checks the invariants of a decoded DVVSet.
+func (s DVVSet[T]) validate() error {
+	for i, e := range s.entries {
+		if i > 0 && e.id <= s.entries[i-1].id {
+			return fmt.Errorf("vclock: id %q in DVVSet is out of order or duplicated", e.id)
+		}
+		if e.counter == 0 || uint64(len(e.values)) > e.counter {
+			return fmt.Errorf("vclock: DVVSet entry %q has counter %d but %d values", e.id, e.counter, len(e.values))
+		}
+	}
+	return nil
+}
+
+// The binary encoding of a DVVSet uses its own magic byte, but otherwise
+// follows the conventions of the binary encoding of vector clocks:
+//
+//	magic (1 byte) | version (1 byte) | count (uvarint) | entries... | anonymous values
+//
+// where each entry is
+//
+//	id length (uvarint) | id (bytes) | counter (uvarint) | values
+//
+// and a list of values is a uvarint count followed by that many values, each
+// prefixed with its length as a uvarint.
+const dvvSetMagic byte = 0xc8
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. It supports
+// values of type string and values that implement encoding.BinaryMarshaler.
+func (s DVVSet[T]) MarshalBinary() ([]byte, error) {
+	b := []byte{dvvSetMagic, binaryVersion}
+	b = binary.AppendUvarint(b, uint64(len(s.entries)))
+
+	var err error
+	for _, e := range s.entries {
+		b = binary.AppendUvarint(b, uint64(len(e.id)))
+		b = append(b, e.id...)
+		b = binary.AppendUvarint(b, e.counter)
+		if b, err = appendValues(b, e.values); err != nil {
+			return nil, err
+		}
+	}
+	return appendValues(b, s.anonymous)
+}
+
+func appendValues[T comparable](b []byte, values []T) ([]byte, error) {
+	b = binary.AppendUvarint(b, uint64(len(values)))
+	for _, v := range values {
+		var data []byte
+		switch v := interface{}(v).(type) {
+		case string:
+			data = []byte(v)
+		case encoding.BinaryMarshaler:
+			var err error
+			if data, err = v.MarshalBinary(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("vclock: cannot encode DVVSet values of type %T", v)
+		}
+		b = binary.AppendUvarint(b, uint64(len(data)))
+		b = append(b, data...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface. It
+// supports values of type string and values whose pointers implement
+// encoding.BinaryUnmarshaler. The callee is replaced by the decoded DVVSet.
+func (s *DVVSet[T]) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errTruncated
+	}
+	if data[0] != dvvSetMagic {
+		return fmt.Errorf("vclock: invalid magic byte 0x%02x in binary DVVSet", data[0])
+	}
+	if data[1] != binaryVersion {
+		return fmt.Errorf("vclock: unsupported binary DVVSet version %d", data[1])
+	}
+	d := binaryDecoder{data: data[2:]}
+
+	var decoded DVVSet[T]
+	count := d.uvarint()
+	for i := uint64(0); i < count && d.err == nil; i++ {
+		e := dvvSetEntry[T]{id: string(d.bytes()), counter: d.uvarint()}
+		e.values = readValues[T](&d)
+		decoded.entries = append(decoded.entries, e)
+	}
+	decoded.anonymous = readValues[T](&d)
+
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) > 0 {
+		return fmt.Errorf("vclock: %d trailing bytes after binary DVVSet", len(d.data))
+	}
+	if err := decoded.validate(); err != nil {
+		return err
+	}
+
+	*s = decoded
+	return nil
+}
+
+func readValues[T comparable](d *binaryDecoder) []T {
+	count := d.uvarint()
+
+	var values []T
+	for i := uint64(0); i < count && d.err == nil; i++ {
+		data := d.bytes()
+		if d.err != nil {
+			break
+		}
+
+		var v T
+		switch p := interface{}(&v).(type) {
+		case *string:
+			*p = string(data)
+		case encoding.BinaryUnmarshaler:
+			if err := p.UnmarshalBinary(data); err != nil {
+				d.err = err
+				return nil
+			}
+		default:
+			d.err = fmt.Errorf("vclock: cannot decode DVVSet values of type %T", v)
+			return nil
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// binaryDecoder reads uvarints and length-prefixed byte slices from a buffer.
+// After the first error, all reads return zero values and err is set.
+type binaryDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = errTruncated
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *binaryDecoder) bytes() []byte {
+	length := d.uvarint()
+	if d.err != nil {
+		return nil
+	}
+	if length > uint64(len(d.data)) {
+		d.err = errTruncated
+		return nil
+	}
+	b := d.data[:length]
+	d.data = d.data[length:]
+	return b
+}
@@ -0,0 +1,178 @@
+package vclock
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// dvvSetString renders a DVVSet in the notation of Riak's dvvset tests, e.g.,
+// {[{a,2,[v2]}],[]}.
+func dvvSetString(s DVVSet[string]) string {
+	var entries []string
+	for _, e := range s.entries {
+		entries = append(entries, fmt.Sprintf("{%s,%d,[%s]}", e.id, e.counter, strings.Join(e.values, ",")))
+	}
+	return fmt.Sprintf("{[%s],[%s]}", strings.Join(entries, ","), strings.Join(s.anonymous, ","))
+}
+
+func checkDVVSet(t *testing.T, name string, s DVVSet[string], expected string) {
+	if got := dvvSetString(s); got != expected {
+		t.Fatalf("%s: %s not the same as expected %s", name, got, expected)
+	}
+}
+
+// The following tests are adapted from the tests of Riak's dvvset module.
+
+func TestDVVSetUpdate(t *testing.T) {
+	a0 := NewDVVSet(nil, "v1").Update("a", DVVSet[string]{})
+	a1 := NewDVVSet(a0.Join(), "v2").Update("a", a0)
+	a2 := NewDVVSet(a1.Join(), "v3").Update("b", a1)
+	a3 := NewDVVSet(a0.Join(), "v4").Update("b", a1)
+	a4 := NewDVVSet(a0.Join(), "v5").Update("a", a1)
+
+	checkDVVSet(t, "a0", a0, "{[{a,1,[v1]}],[]}")
+	checkDVVSet(t, "a1", a1, "{[{a,2,[v2]}],[]}")
+	checkDVVSet(t, "a2", a2, "{[{a,2,[]},{b,1,[v3]}],[]}")
+	checkDVVSet(t, "a3", a3, "{[{a,2,[v2]},{b,1,[v4]}],[]}")
+	checkDVVSet(t, "a4", a4, "{[{a,3,[v5,v2]}],[]}")
+}
+
+func TestDVVSetSync(t *testing.T) {
+	x := DVVSet[string]{entries: []dvvSetEntry[string]{{id: "x", counter: 1}}}
+	a := NewDVVSet(nil, "v1").Update("a", DVVSet[string]{})
+	y := NewDVVSet(nil, "v2").Update("b", DVVSet[string]{})
+	a1 := NewDVVSet(a.Join(), "v2").Update("a", DVVSet[string]{})
+	a3 := NewDVVSet(a1.Join(), "v3").Update("b", DVVSet[string]{})
+	a4 := NewDVVSet(a1.Join(), "v3").Update("c", DVVSet[string]{})
+	w := DVVSet[string]{entries: []dvvSetEntry[string]{{id: "a", counter: 1}}}
+	z := DVVSet[string]{entries: []dvvSetEntry[string]{{id: "a", counter: 2, values: []string{"v2", "v1"}}}}
+
+	checkDVVSet(t, "sync(w, z)", w.Sync(z), "{[{a,2,[v2]}],[]}")
+	checkDVVSet(t, "sync(z, w)", z.Sync(w), "{[{a,2,[v2]}],[]}")
+	checkDVVSet(t, "sync(a, a1)", a.Sync(a1), dvvSetString(a1.Sync(a)))
+	checkDVVSet(t, "sync(a4, a3)", a4.Sync(a3), "{[{a,2,[]},{b,1,[v3]},{c,1,[v3]}],[]}")
+	checkDVVSet(t, "sync(a3, a4)", a3.Sync(a4), "{[{a,2,[]},{b,1,[v3]},{c,1,[v3]}],[]}")
+	checkDVVSet(t, "sync(x, a)", x.Sync(a), "{[{a,1,[v1]},{x,1,[]}],[]}")
+	checkDVVSet(t, "sync(a, y)", a.Sync(y), "{[{a,1,[v1]},{b,1,[v2]}],[]}")
+	checkDVVSet(t, "sync(a, a)", a.Sync(a), dvvSetString(a))
+}
+
+func TestDVVSetSyncAnonymous(t *testing.T) {
+	a := NewDVVSet(VClock{"a": 1}, "v1")
+	b := NewDVVSet(VClock{"b": 1}, "v2")
+	newer := NewDVVSet(VClock{"a": 2}, "v3")
+
+	checkDVVSet(t, "sync(a, b)", a.Sync(b), "{[{a,1,[]},{b,1,[]}],[v1,v2]}")
+	checkDVVSet(t, "sync(a, a)", a.Sync(a), "{[{a,1,[]}],[v1]}")
+	checkDVVSet(t, "sync(a, newer)", a.Sync(newer), "{[{a,2,[]}],[v3]}")
+	checkDVVSet(t, "sync(newer, a)", newer.Sync(a), "{[{a,2,[]}],[v3]}")
+}
+
+func TestDVVSetValues(t *testing.T) {
+	a := NewDVVSet(nil, "v1").Update("a", DVVSet[string]{})
+	b := NewDVVSet(nil, "v2").Update("b", a)
+	c := NewDVVSet(nil, "v3").Update("a", b)
+
+	values := c.Values()
+	sort.Strings(values)
+	if strings.Join(values, ",") != "v1,v2,v3" || c.Size() != 3 {
+		t.Fatalf("unexpected values %v", values)
+	}
+
+	if c.Join().ReturnVCString() != "{\"a\":2, \"b\":1}" {
+		t.Fatalf("unexpected join %s", c.Join().ReturnVCString())
+	}
+
+	r := c.Reconcile(func(values []string) string {
+		sort.Strings(values)
+		return strings.Join(values, "+")
+	})
+	checkDVVSet(t, "reconcile", r, "{[{a,2,[]},{b,1,[]}],[v1+v2+v3]}")
+
+	l := c.LWW(func(a, b string) bool { return a < b })
+	checkDVVSet(t, "lww", l, "{[{a,2,[]},{b,1,[]}],[v3]}")
+
+	// writing the reconciled value replaces all siblings
+	checkDVVSet(t, "update", l.Update("a", c), "{[{a,3,[v3]},{b,1,[]}],[]}")
+}
+
+func TestDVVSetReconcileEmpty(t *testing.T) {
+	s := NewDVVSet[string](VClock{"a": 2})
+
+	r := s.Reconcile(func(values []string) string {
+		t.Fatalf("reconcile function called without values")
+		return ""
+	})
+	checkDVVSet(t, "reconcile", r, "{[{a,2,[]}],[]}")
+
+	l := s.LWW(func(a, b string) bool { return a < b })
+	checkDVVSet(t, "lww", l, "{[{a,2,[]}],[]}")
+
+	checkDVVSet(t, "lww of zero value", DVVSet[string]{}.LWW(func(a, b string) bool { return a < b }), "{[],[]}")
+}
+
+func TestDVVSetJSONRoundTrip(t *testing.T) {
+	s := NewDVVSet(nil, "v1").Update("a", DVVSet[string]{})
+	s = NewDVVSet(nil, "v2").Update("b", s).Sync(NewDVVSet(VClock{"c": 1}, "v3"))
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded DVVSet[string]
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	checkDVVSet(t, "decoded", decoded, dvvSetString(s))
+}
+
+func TestDVVSetBinaryRoundTrip(t *testing.T) {
+	s := NewDVVSet(nil, "v1").Update("a", DVVSet[string]{})
+	s = NewDVVSet(nil, "v2").Update("b", s).Sync(NewDVVSet(VClock{"c": 1}, "v3"))
+
+	b, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded DVVSet[string]
+	if err := decoded.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+
+	checkDVVSet(t, "decoded", decoded, dvvSetString(s))
+
+	for i := 0; i < len(b); i++ {
+		if err := decoded.UnmarshalBinary(b[:i]); err == nil {
+			t.Fatalf("expected error for truncated DVVSet of %d bytes", i)
+		}
+	}
+}
+
+func TestDVVSetBinaryUnsupportedType(t *testing.T) {
+	s := NewDVVSet(nil, 42)
+
+	if _, err := s.MarshalBinary(); err == nil {
+		t.Fatalf("expected error for int values")
+	}
+}
+
+func TestDVVSetUnmarshalInvalid(t *testing.T) {
+	inputs := []string{
+		`{"entries":[{"id":"b","counter":1,"values":[]},{"id":"a","counter":1,"values":[]}],"anonymous":[]}`,
+		`{"entries":[{"id":"a","counter":1,"values":["v1","v2"]}],"anonymous":[]}`,
+		`{"entries":[{"id":"a","counter":0,"values":[]}],"anonymous":[]}`,
+	}
+
+	for _, input := range inputs {
+		var decoded DVVSet[string]
+		if err := json.Unmarshal([]byte(input), &decoded); err == nil {
+			t.Fatalf("expected error for %s", input)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package vclock
+
+import (
+	"math"
+	"testing"
+)
+
+// fakeTime is a physical clock for tests that only moves when told to.
+type fakeTime struct {
+	t int64
+}
+
+func (f *fakeTime) now() int64 {
+	return f.t
+}
+
+func checkHLC(t *testing.T, name string, got HLCTimestamp, wall int64, logical uint32) {
+	t.Helper()
+
+	if got != (HLCTimestamp{Wall: wall, Logical: logical}) {
+		t.Fatalf("%s: expected %d.%d, got %s", name, wall, logical, got)
+	}
+}
+
+func TestHLCNow(t *testing.T) {
+	pt := &fakeTime{t: 10}
+	h := NewHLC(pt.now)
+
+	checkHLC(t, "first", h.Now(), 10, 0)
+	checkHLC(t, "same physical time", h.Now(), 10, 1)
+
+	pt.t = 5
+	checkHLC(t, "physical time goes backwards", h.Now(), 10, 2)
+
+	pt.t = 20
+	checkHLC(t, "physical time moves on", h.Now(), 20, 0)
+	checkHLC(t, "last", h.Last(), 20, 0)
+}
+
+func TestHLCUpdate(t *testing.T) {
+	pt := &fakeTime{t: 10}
+	h := NewHLC(pt.now)
+	h.Now()
+
+	checkHLC(t, "remote from the past", h.Update(HLCTimestamp{Wall: 5, Logical: 7}), 10, 1)
+	checkHLC(t, "remote from the future", h.Update(HLCTimestamp{Wall: 30, Logical: 3}), 30, 4)
+	checkHLC(t, "same wall, remote ahead", h.Update(HLCTimestamp{Wall: 30, Logical: 9}), 30, 10)
+	checkHLC(t, "same wall, local ahead", h.Update(HLCTimestamp{Wall: 30, Logical: 2}), 30, 11)
+
+	pt.t = 40
+	checkHLC(t, "physical time ahead of both", h.Update(HLCTimestamp{Wall: 35, Logical: 1}), 40, 0)
+}
+
+func TestHLCLogicalOverflow(t *testing.T) {
+	pt := &fakeTime{t: 10}
+	h := NewHLC(pt.now)
+
+	checkHLC(t, "overflow", h.Update(HLCTimestamp{Wall: 10, Logical: math.MaxUint32}), 11, 0)
+}
+
+func TestHLCMonotonic(t *testing.T) {
+	h := NewHLC(nil)
+
+	last := h.Now()
+	for i := 0; i < 1000; i++ {
+		next := h.Now()
+		if next.Compare(last) <= 0 {
+			t.Fatalf("timestamp %s not after %s", next, last)
+		}
+		last = next
+	}
+}
+
+func TestStampOrder(t *testing.T) {
+	c1 := New()
+	c1.Set("a", 1)
+	c2 := New()
+	c2.Set("b", 1)
+	c3 := c1.Copy()
+	c3.Merge(c2)
+
+	early := HLCTimestamp{Wall: 10}
+	late := HLCTimestamp{Wall: 20}
+
+	s1 := Stamp{Time: late, Clock: c1}
+	s2 := Stamp{Time: early, Clock: c2}
+	s3 := Stamp{Time: early, Clock: c3}
+
+	// causality wins over the timestamps
+	if s1.Order(s3) != Descendant || s3.Order(s1) != Ancestor {
+		t.Fatalf("causally related stamps not ordered by their clocks")
+	}
+
+	// concurrent clocks are ordered by their timestamps
+	if s1.Order(s2) != Ancestor || s2.Order(s1) != Descendant || !s2.Compare(s1, Descendant) {
+		t.Fatalf("concurrent stamps not ordered by their timestamps")
+	}
+
+	// with equal timestamps, the order is still deterministic and antisymmetric
+	s4 := Stamp{Time: early, Clock: c1}
+	if s4.Order(s2) == Concurrent || s4.Order(s2) == s2.Order(s4) {
+		t.Fatalf("concurrent stamps with equal timestamps not ordered")
+	}
+
+	if s1.Order(Stamp{Time: early, Clock: c1.Copy()}) != Equal {
+		t.Fatalf("stamps with equal clocks not defined as Equal")
+	}
+}
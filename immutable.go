@@ -0,0 +1,296 @@
+package vclock
+
+// ImmutableClock is a vector clock that never changes. Tick, Set, and Merge
+// return a new clock and leave the callee untouched, so an ImmutableClock can
+// be shared between goroutines or kept in a cache without copying.
+//
+// The entries are kept in a persistent treap, a binary search tree ordered by
+// process id that is balanced by priorities derived from a hash of the ids.
+// Updates copy only the path from the root to the changed entry and share the
+// rest of the tree with the original clock, so Tick and Set take O(log n) time
+// and memory. Since the priorities only depend on the ids, clocks with the same
+// ids have the same shape, which lets Merge share whole subtrees.
+//
+// The zero value is an empty clock ready to use.
+type ImmutableClock struct {
+	root *treapNode
+}
+
+// treapNode is a node of the treap of an ImmutableClock. Nodes are never
+// modified once they are part of a clock.
+type treapNode struct {
+	id    string
+	ticks uint64
+	prio  uint64
+	size  int
+	left  *treapNode
+	right *treapNode
+}
+
+// NewImmutableClock returns an immutable copy of the given vector clock.
+func NewImmutableClock(vc VClock) ImmutableClock {
+	var c ImmutableClock
+	for _, id := range vc.sortedIDs() {
+		c = c.Set(id, vc[id])
+	}
+	return c
+}
+
+// VClock returns the clock as a new VClock.
+func (c ImmutableClock) VClock() VClock {
+	vc := make(VClock, c.Len())
+	c.Range(func(id string, ticks uint64) bool {
+		vc[id] = ticks
+		return true
+	})
+	return vc
+}
+
+// Len returns the number of entries of the clock.
+func (c ImmutableClock) Len() int {
+	return c.root.len()
+}
+
+// FindTicks returns the clock value for a given id or false if the id is not
+// found.
+func (c ImmutableClock) FindTicks(id string) (uint64, bool) {
+	n := c.root
+	for n != nil {
+		switch {
+		case id < n.id:
+			n = n.left
+		case id > n.id:
+			n = n.right
+		default:
+			return n.ticks, true
+		}
+	}
+	return 0, false
+}
+
+// Range calls f for every entry of the clock in ascending order of the process
+// ids. If f returns false, Range stops.
+func (c ImmutableClock) Range(f func(id string, ticks uint64) bool) {
+	it := newTreapIter(c.root)
+	for n := it.next(); n != nil; n = it.next() {
+		if !f(n.id, n.ticks) {
+			return
+		}
+	}
+}
+
+// Set returns a clock with the clock value of the given process id set to the
+// given value.
+func (c ImmutableClock) Set(id string, ticks uint64) ImmutableClock {
+	return ImmutableClock{root: treapSet(c.root, id, ticks, treapPriority(id))}
+}
+
+// Tick returns a clock with the clock value of the given process id
+// incremented by 1. If the process id is not found in the clock, it is added
+// with a value of 1.
+func (c ImmutableClock) Tick(id string) ImmutableClock {
+	ticks, _ := c.FindTicks(id)
+	return c.Set(id, ticks+1)
+}
+
+// Merge returns a clock with the maximum of the clock values of c and other
+// for every process id, see VClock.Merge.
+func (c ImmutableClock) Merge(other ImmutableClock) ImmutableClock {
+	return ImmutableClock{root: treapUnion(c.root, other.root)}
+}
+
+// Order determines the relationship between two clocks, see VClock.Order.
+// Like VClock.Order, it treats entries with a clock value of 0 like any other
+// entry.
+func (c ImmutableClock) Order(other ImmutableClock) Condition {
+	if c.root == other.root {
+		return Equal
+	}
+
+	cBigger := false
+	otherBigger := false
+
+	it, otherIt := newTreapIter(c.root), newTreapIter(other.root)
+	n, o := it.next(), otherIt.next()
+	for (n != nil || o != nil) && !(cBigger && otherBigger) {
+		switch {
+		case o == nil || (n != nil && n.id < o.id):
+			cBigger = true
+			n = it.next()
+		case n == nil || o.id < n.id:
+			otherBigger = true
+			o = otherIt.next()
+		default:
+			if n.ticks > o.ticks {
+				cBigger = true
+			} else if n.ticks < o.ticks {
+				otherBigger = true
+			}
+			n, o = it.next(), otherIt.next()
+		}
+	}
+
+	return condition(cBigger, otherBigger)
+}
+
+// Compare takes another clock and determines if it is Equal, an Ancestor,
+// Descendant, or Concurrent with the callee according to Order. The condition
+// may be ORed, see VClock.Compare.
+func (c ImmutableClock) Compare(other ImmutableClock, cond Condition) bool {
+	return c.Order(other)&cond != 0
+}
+
+// String returns the clock in the format of VClock.ReturnVCString.
+func (c ImmutableClock) String() string {
+	dst := []byte{'{'}
+	c.Range(func(id string, ticks uint64) bool {
+		dst = appendEntry(dst, len(dst) == 1, id, ticks)
+		return true
+	})
+	return string(append(dst, '}'))
+}
+
+// treapPriority returns the priority of the node for the given id, the FNV-1a
+// hash of the id. FNV-1a hardly changes the high bits for ids that only differ
+// in their last bytes, such as "node-1" and "node-2", so the hash is mixed
+// with the finalizer of SplitMix64 to keep the treap balanced.
+func treapPriority(id string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(id); i++ {
+		h ^= uint64(id[i])
+		h *= 1099511628211
+	}
+
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// newTreapNode returns a new node with the given fields.
+func newTreapNode(id string, ticks, prio uint64, left, right *treapNode) *treapNode {
+	return &treapNode{
+		id:    id,
+		ticks: ticks,
+		prio:  prio,
+		size:  1 + left.len() + right.len(),
+		left:  left,
+		right: right,
+	}
+}
+
+func (n *treapNode) len() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// above reports whether a node with the given priority and id belongs above n
+// in the treap. Hash collisions are broken by the ids.
+func (n *treapNode) above(prio uint64, id string) bool {
+	return prio > n.prio || (prio == n.prio && id < n.id)
+}
+
+// treapSet returns the treap n with the clock value of id set to ticks.
+func treapSet(n *treapNode, id string, ticks, prio uint64) *treapNode {
+	if n == nil {
+		return newTreapNode(id, ticks, prio, nil, nil)
+	}
+
+	switch {
+	case id == n.id:
+		if ticks == n.ticks {
+			return n
+		}
+		return newTreapNode(id, ticks, prio, n.left, n.right)
+	case n.above(prio, id):
+		// every node of n has a lower priority, so id is not part of n
+		left, _, right := treapSplit(n, id)
+		return newTreapNode(id, ticks, prio, left, right)
+	case id < n.id:
+		return newTreapNode(n.id, n.ticks, n.prio, treapSet(n.left, id, ticks, prio), n.right)
+	default:
+		return newTreapNode(n.id, n.ticks, n.prio, n.left, treapSet(n.right, id, ticks, prio))
+	}
+}
+
+// treapSplit splits the treap n into the nodes with ids less than id, the node
+// with the given id, if there is one, and the nodes with ids greater than id.
+func treapSplit(n *treapNode, id string) (*treapNode, *treapNode, *treapNode) {
+	switch {
+	case n == nil:
+		return nil, nil, nil
+	case id < n.id:
+		left, match, right := treapSplit(n.left, id)
+		return left, match, newTreapNode(n.id, n.ticks, n.prio, right, n.right)
+	case id > n.id:
+		left, match, right := treapSplit(n.right, id)
+		return newTreapNode(n.id, n.ticks, n.prio, n.left, left), match, right
+	default:
+		return n.left, n, n.right
+	}
+}
+
+// treapUnion returns the union of the treaps a and b, keeping the maximum
+// clock value for ids that are part of both. Subtrees that do not change are
+// shared with the result.
+func treapUnion(a, b *treapNode) *treapNode {
+	switch {
+	case a == nil:
+		return b
+	case b == nil || a == b:
+		return a
+	}
+
+	if a.above(b.prio, b.id) {
+		a, b = b, a
+	}
+
+	left, match, right := treapSplit(b, a.id)
+	left = treapUnion(a.left, left)
+	right = treapUnion(a.right, right)
+
+	ticks := a.ticks
+	if match != nil && match.ticks > ticks {
+		ticks = match.ticks
+	}
+
+	if left == a.left && right == a.right && ticks == a.ticks {
+		return a
+	}
+	if match != nil && left == match.left && right == match.right && ticks == match.ticks {
+		return match
+	}
+	return newTreapNode(a.id, ticks, a.prio, left, right)
+}
+
+// treapIter iterates over the nodes of a treap in ascending order of their ids.
+type treapIter struct {
+	stack []*treapNode
+}
+
+func newTreapIter(n *treapNode) *treapIter {
+	it := &treapIter{}
+	it.pushLeft(n)
+	return it
+}
+
+func (it *treapIter) pushLeft(n *treapNode) {
+	for ; n != nil; n = n.left {
+		it.stack = append(it.stack, n)
+	}
+}
+
+// next returns the next node, or nil if there are no more nodes.
+func (it *treapIter) next() *treapNode {
+	if len(it.stack) == 0 {
+		return nil
+	}
+	n := it.stack[len(it.stack)-1]
+	it.stack = it.stack[:len(it.stack)-1]
+	it.pushLeft(n.right)
+	return n
+}
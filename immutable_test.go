@@ -0,0 +1,176 @@
+package vclock
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// checkTreap checks the invariants of the treap of an ImmutableClock: ids are
+// in search tree order, priorities in heap order, and sizes are correct.
+func checkTreap(t *testing.T, n *treapNode, lo, hi string) int {
+	t.Helper()
+
+	if n == nil {
+		return 0
+	}
+	if (lo != "" && n.id <= lo) || (hi != "" && n.id >= hi) {
+		t.Fatalf("id %q out of order", n.id)
+	}
+	if n.prio != treapPriority(n.id) {
+		t.Fatalf("wrong priority for %q", n.id)
+	}
+	for _, child := range []*treapNode{n.left, n.right} {
+		if child != nil && n.above(child.prio, child.id) {
+			t.Fatalf("child %q above parent %q", child.id, n.id)
+		}
+	}
+
+	size := 1 + checkTreap(t, n.left, lo, n.id) + checkTreap(t, n.right, n.id, hi)
+	if n.size != size {
+		t.Fatalf("wrong size %d for %q, expected %d", n.size, n.id, size)
+	}
+	return size
+}
+
+func TestImmutableClockAgreesWithVClock(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		a, b := randomVClock(r, 8), randomVClock(r, 8)
+		ia, ib := NewImmutableClock(a), NewImmutableClock(b)
+		checkTreap(t, ia.root, "", "")
+
+		if ia.String() != a.ReturnVCString() || !reflect.DeepEqual(ia.VClock(), a) || ia.Len() != len(a) {
+			t.Fatalf("conversion of %s gave %s", a.ReturnVCString(), ia)
+		}
+		if ia.Order(ib) != a.Order(b) || !ia.Compare(ib, a.Order(b)) {
+			failComparison(t, "Order does not agree with VClock: a = %s | b = %s", a, b)
+		}
+
+		id := strconv.Itoa(r.Intn(10))
+		ticked := ia.Tick(id)
+		a.Tick(id)
+		checkTreap(t, ticked.root, "", "")
+		if ticked.String() != a.ReturnVCString() {
+			t.Fatalf("Tick gave %s, expected %s", ticked, a.ReturnVCString())
+		}
+
+		m := ticked.Merge(ib)
+		a.Merge(b)
+		checkTreap(t, m.root, "", "")
+		if m.String() != a.ReturnVCString() {
+			t.Fatalf("Merge gave %s, expected %s", m, a.ReturnVCString())
+		}
+	}
+}
+
+func TestImmutableClockUnchanged(t *testing.T) {
+	c := NewImmutableClock(VClock{"a": 1, "b": 2})
+	s := c.String()
+
+	c.Tick("a")
+	c.Set("c", 5)
+	c.Merge(NewImmutableClock(VClock{"a": 7, "d": 1}))
+
+	if c.String() != s {
+		t.Fatalf("clock changed from %s to %s", s, c)
+	}
+
+	var zero ImmutableClock
+	if zero.Len() != 0 || zero.String() != "{}" || zero.Tick("a").String() != "{\"a\":1}" {
+		t.Fatalf("zero value not an empty clock")
+	}
+}
+
+func TestImmutableClockSharing(t *testing.T) {
+	base := NewImmutableClock(genVClock(1000))
+	ticked := base.Tick("500")
+
+	// Merge returns the descendant itself if nothing changes
+	if m := base.Merge(ticked); m.root != ticked.root {
+		t.Fatalf("Merge of an ancestor did not share the descendant")
+	}
+	if base.Order(ticked) != Descendant || ticked.Order(base) != Ancestor {
+		t.Fatalf("ticked clock not a descendant")
+	}
+
+	// only the path to the ticked entry is copied
+	shared := 0
+	var count func(a, b *treapNode)
+	count = func(a, b *treapNode) {
+		if a == nil || b == nil {
+			return
+		}
+		if a == b {
+			shared += a.size
+			return
+		}
+		count(a.left, b.left)
+		count(a.right, b.right)
+	}
+	count(base.root, ticked.root)
+	if shared < 970 {
+		t.Fatalf("only %d of 1000 entries shared after Tick", shared)
+	}
+}
+
+func BenchmarkImmutableClockTick(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			c := NewImmutableClock(genVClock(size))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Tick("0")
+			}
+		})
+	}
+}
+
+func BenchmarkCopyTick(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			vc := genVClock(size)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				vc.Copy().Tick("0")
+			}
+		})
+	}
+}
+
+func BenchmarkImmutableClockMerge(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			c1 := NewImmutableClock(genVClock(size))
+			c2 := c1.Tick("0")
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c2.Merge(c1)
+			}
+		})
+	}
+}
+
+func BenchmarkCopyMerge(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			vc1 := genVClock(size)
+			vc2 := vc1.Copy()
+			vc2.Tick("0")
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				vc2.Copy().Merge(vc1)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Package hb implements the happens-before relation of sets of vector clocks
+// shared by the shiviz and dot packages.
+package hb
+
+import (
+	"sort"
+
+	"git.tu-berlin.de/mcc-fred/vclock"
+)
+
+// Reduce returns the direct predecessors of every clock in the transitive
+// reduction of the happens-before relation, in ascending order. Clocks that
+// are equal are not related.
+func Reduce(clocks []vclock.VClock) [][]int {
+	n := len(clocks)
+
+	// before[j][i] is true if clocks[i] happens before clocks[j]
+	before := make([][]bool, n)
+	for j := range clocks {
+		before[j] = make([]bool, n)
+		for i := range clocks {
+			before[j][i] = clocks[i].Order(clocks[j]) == vclock.Descendant
+		}
+	}
+
+	// an event has more predecessors than every event that happens before it,
+	// so a later event in this order never happens before an earlier one
+	topo := make([]int, n)
+	counts := make([]int, n)
+	for j := range clocks {
+		topo[j] = j
+		for _, ok := range before[j] {
+			if ok {
+				counts[j]++
+			}
+		}
+	}
+	sort.SliceStable(topo, func(a, b int) bool { return counts[topo[a]] < counts[topo[b]] })
+
+	preds := make([][]int, n)
+	for j := range clocks {
+		// walk the candidates from the latest to the earliest, a candidate is a
+		// direct predecessor unless it happens before one that was chosen
+		covered := make([]bool, n)
+		for k := n - 1; k >= 0; k-- {
+			i := topo[k]
+			if !before[j][i] || covered[i] {
+				continue
+			}
+			preds[j] = append(preds[j], i)
+			for a, ok := range before[i] {
+				if ok {
+					covered[a] = true
+				}
+			}
+		}
+		sort.Ints(preds[j])
+	}
+	return preds
+}
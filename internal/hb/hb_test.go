@@ -0,0 +1,72 @@
+package hb
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"git.tu-berlin.de/mcc-fred/vclock"
+)
+
+// TestReduceRandom compares the reduction with the definition: i is a direct
+// predecessor of j if i happens before j and there is no k in between.
+func TestReduceRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ids := []string{"a", "b", "c", "d"}
+
+	clocks := map[string]vclock.VClock{}
+	for _, id := range ids {
+		clocks[id] = vclock.New()
+	}
+	var events []vclock.VClock
+	for i := 0; i < 80; i++ {
+		id := ids[r.Intn(len(ids))]
+		if r.Intn(2) == 0 {
+			clocks[id].Merge(clocks[ids[r.Intn(len(ids))]])
+		}
+		clocks[id].Tick(id)
+		// entries with a clock value of 0 add nothing to the sum of the clock
+		// values, but still order the clocks
+		if other := ids[r.Intn(len(ids))]; r.Intn(4) == 0 {
+			if _, ok := clocks[id].FindTicks(other); !ok {
+				clocks[id].Set(other, 0)
+			}
+		}
+		events = append(events, clocks[id].Copy())
+	}
+	r.Shuffle(len(events), func(i, j int) { events[i], events[j] = events[j], events[i] })
+
+	hb := func(i, j int) bool { return events[i].Order(events[j]) == vclock.Descendant }
+
+	preds := Reduce(events)
+	for j := range events {
+		var expected []int
+		for i := range events {
+			if !hb(i, j) {
+				continue
+			}
+			direct := true
+			for k := range events {
+				if hb(i, k) && hb(k, j) {
+					direct = false
+					break
+				}
+			}
+			if direct {
+				expected = append(expected, i)
+			}
+		}
+		if fmt.Sprint(preds[j]) != fmt.Sprint(expected) {
+			t.Fatalf("direct predecessors of %s: expected %v, got %v", events[j].ReturnVCString(), expected, preds[j])
+		}
+	}
+}
+
+func TestReduceZeroEntries(t *testing.T) {
+	events := []vclock.VClock{{"a": 1, "b": 0}, {"a": 1}, {"a": 2, "b": 0}}
+
+	preds := Reduce(events)
+	if fmt.Sprint(preds) != "[[1] [] [0]]" {
+		t.Fatalf("unexpected predecessors %v", preds)
+	}
+}
@@ -0,0 +1,627 @@
+package vclock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ITC is a stamp of an interval tree clock as described by Almeida et al. in
+// "Interval Tree Clocks: A Logical Clock for Dynamic Systems"
+// (https://doi.org/10.1007/978-3-540-92221-6_18). Unlike vector clocks,
+// interval tree clocks do not need a global set of process ids. New
+// participants get their identity by forking an existing stamp, and leaving
+// participants give it back by joining their stamp into another one, so the
+// clock does not grow with every process that ever took part.
+//
+// A stamp consists of an id, which is the part of the interval [0, 1) that the
+// participant owns, and an event tree, which records the causal history. ITC
+// values are immutable, all operations return new stamps. The zero value is an
+// anonymous stamp without events, see Peek.
+type ITC struct {
+	id    *itcID
+	event *itcEvent
+}
+
+// ErrAnonymousITC is returned by ITC.Event for stamps that do not own any
+// part of the id space, e.g., stamps returned by Peek.
+var ErrAnonymousITC = errors.New("vclock: cannot register an event on an anonymous ITC stamp")
+
+// itcID is an id tree. A leaf is either 0 or 1, an inner node splits its
+// interval into two halves.
+type itcID struct {
+	one         bool
+	left, right *itcID
+}
+
+// itcEvent is an event tree. A leaf holds a counter, an inner node holds a
+// base counter that is added to the counters of both of its subtrees.
+type itcEvent struct {
+	n           uint64
+	left, right *itcEvent
+}
+
+var (
+	itcIDZero = &itcID{}
+	itcIDOne  = &itcID{one: true}
+)
+
+// SeedITC returns the initial stamp of an interval tree clock, which owns the
+// whole id space and has no events. Other participants get their stamps by
+// forking the seed.
+func SeedITC() ITC {
+	return ITC{id: itcIDOne, event: itcLeaf(0)}
+}
+
+func (s ITC) ids() *itcID {
+	if s.id == nil {
+		return itcIDZero
+	}
+	return s.id
+}
+
+func (s ITC) events() *itcEvent {
+	if s.event == nil {
+		return itcLeaf(0)
+	}
+	return s.event
+}
+
+// Fork splits the id of the stamp into two halves and returns two stamps with
+// the same causal history, one for each half. Use it to hand out an identity to
+// a new participant.
+func (s ITC) Fork() (ITC, ITC) {
+	i1, i2 := s.ids().split()
+	return ITC{id: i1, event: s.events()}, ITC{id: i2, event: s.events()}
+}
+
+// Join merges two stamps into one that owns the ids of both stamps and
+// includes the causal history of both. Use it to retire a participant or to
+// receive a stamp from another participant.
+func (s ITC) Join(other ITC) ITC {
+	return ITC{id: itcSum(s.ids(), other.ids()), event: itcJoin(s.events(), other.events())}
+}
+
+// Event returns a stamp that records a new event of the participant. The
+// result is a descendant of s. It returns ErrAnonymousITC if s does not own
+// any part of the id space.
+func (s ITC) Event() (ITC, error) {
+	id := s.ids()
+	if id.isZero() {
+		return s, ErrAnonymousITC
+	}
+
+	e := s.events()
+	if filled := itcFill(id, e); !filled.equal(e) {
+		return ITC{id: id, event: filled}, nil
+	}
+
+	grown, _ := itcGrow(id, e)
+	return ITC{id: id, event: grown}, nil
+}
+
+// Peek returns an anonymous stamp with the causal history of s. Anonymous
+// stamps own no ids and cannot record events, but they can be sent along with
+// messages and joined into other stamps.
+func (s ITC) Peek() ITC {
+	return ITC{id: itcIDZero, event: s.events()}
+}
+
+// Send is a shortcut for an event followed by a peek. It returns the new stamp
+// of the participant and the anonymous stamp to send along with a message.
+func (s ITC) Send() (ITC, ITC, error) {
+	e, err := s.Event()
+	if err != nil {
+		return s, ITC{}, err
+	}
+	return e, e.Peek(), nil
+}
+
+// Order determines the relationship between the causal histories of two
+// stamps. Just like VClock.Order, it returns Ancestor if other is an ancestor
+// of s, Descendant if other is a descendant of s, Equal if the histories are
+// the same, and Concurrent otherwise. The ids of the stamps are not compared.
+func (s ITC) Order(other ITC) Condition {
+	sBigger := !itcLeq(s.events(), 0, other.events(), 0)
+	otherBigger := !itcLeq(other.events(), 0, s.events(), 0)
+	return condition(sBigger, otherBigger)
+}
+
+// Compare takes another stamp and determines if it is Equal, an Ancestor,
+// Descendant, or Concurrent with the callee. The condition may be ORed, see
+// VClock.Compare.
+func (s ITC) Compare(other ITC, cond Condition) bool {
+	return s.Order(other)&cond != 0
+}
+
+// String returns the stamp in the notation of the paper, e.g., ((1, 0), (0, 1, 0)).
+func (s ITC) String() string {
+	var b strings.Builder
+	b.WriteByte('(')
+	s.ids().format(&b)
+	b.WriteString(", ")
+	s.events().format(&b)
+	b.WriteByte(')')
+	return b.String()
+}
+
+// id trees
+
+func itcIDNode(left, right *itcID) *itcID {
+	if left.isLeaf() && right.isLeaf() && left.one == right.one {
+		return left
+	}
+	return &itcID{left: left, right: right}
+}
+
+func (i *itcID) isLeaf() bool {
+	return i.left == nil
+}
+
+func (i *itcID) isZero() bool {
+	return i.isLeaf() && !i.one
+}
+
+func (i *itcID) isOne() bool {
+	return i.isLeaf() && i.one
+}
+
+// split splits an id into two disjoint ids whose sum is i.
+func (i *itcID) split() (*itcID, *itcID) {
+	switch {
+	case i.isZero():
+		return itcIDZero, itcIDZero
+	case i.isOne():
+		return itcIDNode(itcIDOne, itcIDZero), itcIDNode(itcIDZero, itcIDOne)
+	case i.left.isZero():
+		r1, r2 := i.right.split()
+		return itcIDNode(itcIDZero, r1), itcIDNode(itcIDZero, r2)
+	case i.right.isZero():
+		l1, l2 := i.left.split()
+		return itcIDNode(l1, itcIDZero), itcIDNode(l2, itcIDZero)
+	default:
+		return itcIDNode(i.left, itcIDZero), itcIDNode(itcIDZero, i.right)
+	}
+}
+
+// itcSum returns the union of two ids.
+func itcSum(a, b *itcID) *itcID {
+	switch {
+	case a.isZero() || b.isOne():
+		return b
+	case b.isZero() || a.isOne():
+		return a
+	default:
+		return itcIDNode(itcSum(a.left, b.left), itcSum(a.right, b.right))
+	}
+}
+
+func (i *itcID) equal(other *itcID) bool {
+	if i.isLeaf() || other.isLeaf() {
+		return i.isLeaf() && other.isLeaf() && i.one == other.one
+	}
+	return i.left.equal(other.left) && i.right.equal(other.right)
+}
+
+func (i *itcID) format(b *strings.Builder) {
+	switch {
+	case i.isZero():
+		b.WriteByte('0')
+	case i.isOne():
+		b.WriteByte('1')
+	default:
+		b.WriteByte('(')
+		i.left.format(b)
+		b.WriteString(", ")
+		i.right.format(b)
+		b.WriteByte(')')
+	}
+}
+
+// event trees
+
+func itcLeaf(n uint64) *itcEvent {
+	return &itcEvent{n: n}
+}
+
+// itcNode returns the normal form of the event tree (n, left, right).
+func itcNode(n uint64, left, right *itcEvent) *itcEvent {
+	e, _ := itcNodeMin(n, left, left.min(), right, right.min())
+	return e
+}
+
+// itcNodeMin is itcNode for subtrees whose minimums are known. It also returns
+// the minimum of the result.
+func itcNodeMin(n uint64, left *itcEvent, leftMin uint64, right *itcEvent, rightMin uint64) (*itcEvent, uint64) {
+	if left.isLeaf() && right.isLeaf() && left.n == right.n {
+		return itcLeaf(n + left.n), n + left.n
+	}
+
+	m := leftMin
+	if rightMin < m {
+		m = rightMin
+	}
+	return &itcEvent{n: n + m, left: left.sink(m), right: right.sink(m)}, n + m
+}
+
+func (e *itcEvent) isLeaf() bool {
+	return e.left == nil
+}
+
+func (e *itcEvent) min() uint64 {
+	if e.isLeaf() {
+		return e.n
+	}
+	m := e.left.min()
+	if rm := e.right.min(); rm < m {
+		m = rm
+	}
+	return e.n + m
+}
+
+func (e *itcEvent) max() uint64 {
+	if e.isLeaf() {
+		return e.n
+	}
+	m := e.left.max()
+	if rm := e.right.max(); rm > m {
+		m = rm
+	}
+	return e.n + m
+}
+
+func (e *itcEvent) lift(m uint64) *itcEvent {
+	if m == 0 {
+		return e
+	}
+	return &itcEvent{n: e.n + m, left: e.left, right: e.right}
+}
+
+func (e *itcEvent) sink(m uint64) *itcEvent {
+	if m == 0 {
+		return e
+	}
+	return &itcEvent{n: e.n - m, left: e.left, right: e.right}
+}
+
+func (e *itcEvent) equal(other *itcEvent) bool {
+	if e.n != other.n || e.isLeaf() != other.isLeaf() {
+		return false
+	}
+	return e.isLeaf() || (e.left.equal(other.left) && e.right.equal(other.right))
+}
+
+func (e *itcEvent) format(b *strings.Builder) {
+	if e.isLeaf() {
+		b.WriteString(strconv.FormatUint(e.n, 10))
+		return
+	}
+	b.WriteByte('(')
+	b.WriteString(strconv.FormatUint(e.n, 10))
+	b.WriteString(", ")
+	e.left.format(b)
+	b.WriteString(", ")
+	e.right.format(b)
+	b.WriteByte(')')
+}
+
+// itcJoin returns the smallest event tree that is greater than or equal to both
+// a and b.
+func itcJoin(a, b *itcEvent) *itcEvent {
+	switch {
+	case a.isLeaf() && b.isLeaf():
+		if a.n > b.n {
+			return a
+		}
+		return b
+	case a.isLeaf():
+		a = &itcEvent{n: a.n, left: itcLeaf(0), right: itcLeaf(0)}
+	case b.isLeaf():
+		b = &itcEvent{n: b.n, left: itcLeaf(0), right: itcLeaf(0)}
+	}
+
+	if a.n > b.n {
+		a, b = b, a
+	}
+	d := b.n - a.n
+	return itcNode(a.n, itcJoin(a.left, b.left.lift(d)), itcJoin(a.right, b.right.lift(d)))
+}
+
+// itcLeq reports whether the event tree a lifted by aBase is less than or
+// equal to the event tree b lifted by bBase everywhere. The event trees must be
+// in normal form.
+func itcLeq(a *itcEvent, aBase uint64, b *itcEvent, bBase uint64) bool {
+	an := a.n + aBase
+	bn := b.n + bBase
+
+	if an > bn {
+		return false
+	}
+	if a.isLeaf() {
+		return true
+	}
+	if b.isLeaf() {
+		return itcLeq(a.left, an, b, bBase) && itcLeq(a.right, an, b, bBase)
+	}
+	return itcLeq(a.left, an, b.left, bn) && itcLeq(a.right, an, b.right, bn)
+}
+
+// itcFill tries to simplify the event tree e by raising the parts that the
+// id i owns to the maximum of their surroundings.
+func itcFill(i *itcID, e *itcEvent) *itcEvent {
+	switch {
+	case i.isZero():
+		return e
+	case i.isOne():
+		return itcLeaf(e.max())
+	case e.isLeaf():
+		return e
+	case i.left.isOne():
+		right := itcFill(i.right, e.right)
+		left := e.left.max()
+		if m := right.min(); m > left {
+			left = m
+		}
+		return itcNode(e.n, itcLeaf(left), right)
+	case i.right.isOne():
+		left := itcFill(i.left, e.left)
+		right := e.right.max()
+		if m := left.min(); m > right {
+			right = m
+		}
+		return itcNode(e.n, left, itcLeaf(right))
+	default:
+		return itcNode(e.n, itcFill(i.left, e.left), itcFill(i.right, e.right))
+	}
+}
+
+// itcGrowCost is the cost of expanding a leaf of an event tree into a node. It
+// is bigger than any cost of growing an existing subtree, so that expanding
+// the tree is the last resort.
+const itcGrowCost = 1 << 32
+
+// itcGrow increments the event tree e in a part that the id i owns and returns
+// the result and its cost, which prefers small trees. i must not be 0.
+func itcGrow(i *itcID, e *itcEvent) (*itcEvent, uint64) {
+	if e.isLeaf() {
+		if i.isOne() {
+			return itcLeaf(e.n + 1), 0
+		}
+		grown, cost := itcGrow(i, &itcEvent{n: e.n, left: itcLeaf(0), right: itcLeaf(0)})
+		return grown, cost + itcGrowCost
+	}
+
+	switch {
+	case i.isOne():
+		// an id of 1 owns the whole tree, so fill would have flattened it
+		return itcLeaf(e.max() + 1), 0
+	case i.left.isZero():
+		right, cost := itcGrow(i.right, e.right)
+		return itcNode(e.n, e.left, right), cost + 1
+	case i.right.isZero():
+		left, cost := itcGrow(i.left, e.left)
+		return itcNode(e.n, left, e.right), cost + 1
+	}
+
+	left, leftCost := itcGrow(i.left, e.left)
+	right, rightCost := itcGrow(i.right, e.right)
+	if leftCost < rightCost {
+		return itcNode(e.n, left, e.right), leftCost + 1
+	}
+	return itcNode(e.n, e.left, right), rightCost + 1
+}
+
+// The binary encoding of an ITC stamp is
+//
+//	magic (1 byte) | version (1 byte) | id | event
+//
+// where an id is a single byte 0 or 1 for a leaf, or the byte 2 followed by
+// the left and the right subtree, and an event tree is the byte 0 followed by
+// the counter as a uvarint for a leaf, or the byte 1 followed by the base
+// counter as a uvarint and the left and the right subtree.
+const itcMagic byte = 0xc9
+
+// itcMaxDepth is the maximum depth of the id and event trees of a binary
+// encoded stamp, where the root is at depth 0. It bounds the recursion of the
+// operations on stamps decoded from untrusted input. Every Fork may add a level
+// to the id tree, so a stamp that was forked more than itcMaxDepth times in a
+// row without joining cannot be encoded.
+const itcMaxDepth = 1 << 16
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. It returns
+// an error if the id tree or the event tree of the stamp is nested deeper than
+// 65536 levels.
+func (s ITC) MarshalBinary() ([]byte, error) {
+	if s.ids().depth() > itcMaxDepth {
+		return nil, fmt.Errorf("vclock: ITC id is nested deeper than %d levels", itcMaxDepth)
+	}
+	if s.events().depth() > itcMaxDepth {
+		return nil, fmt.Errorf("vclock: ITC event is nested deeper than %d levels", itcMaxDepth)
+	}
+
+	b := []byte{itcMagic, binaryVersion}
+	b = s.ids().appendBinary(b)
+	return s.events().appendBinary(b), nil
+}
+
+func (i *itcID) depth() int {
+	if i.isLeaf() {
+		return 0
+	}
+	d := i.left.depth()
+	if rd := i.right.depth(); rd > d {
+		d = rd
+	}
+	return d + 1
+}
+
+func (e *itcEvent) depth() int {
+	if e.isLeaf() {
+		return 0
+	}
+	d := e.left.depth()
+	if rd := e.right.depth(); rd > d {
+		d = rd
+	}
+	return d + 1
+}
+
+func (i *itcID) appendBinary(b []byte) []byte {
+	switch {
+	case i.isZero():
+		return append(b, 0)
+	case i.isOne():
+		return append(b, 1)
+	default:
+		b = append(b, 2)
+		b = i.left.appendBinary(b)
+		return i.right.appendBinary(b)
+	}
+}
+
+func (e *itcEvent) appendBinary(b []byte) []byte {
+	if e.isLeaf() {
+		b = append(b, 0)
+		return binary.AppendUvarint(b, e.n)
+	}
+	b = append(b, 1)
+	b = binary.AppendUvarint(b, e.n)
+	b = e.left.appendBinary(b)
+	return e.right.appendBinary(b)
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface. The
+// callee is replaced by the decoded stamp. Like MarshalBinary, it returns an
+// error for trees that are nested deeper than 65536 levels.
+func (s *ITC) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errTruncated
+	}
+	if data[0] != itcMagic {
+		return fmt.Errorf("vclock: invalid magic byte 0x%02x in binary ITC", data[0])
+	}
+	if data[1] != binaryVersion {
+		return fmt.Errorf("vclock: unsupported binary ITC version %d", data[1])
+	}
+	d := binaryDecoder{data: data[2:]}
+
+	id := d.itcID()
+	event := d.itcEvent()
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) > 0 {
+		return fmt.Errorf("vclock: %d trailing bytes after binary ITC", len(d.data))
+	}
+
+	*s = ITC{id: id, event: event}
+	return nil
+}
+
+func (d *binaryDecoder) tag() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.data) == 0 {
+		d.err = errTruncated
+		return 0
+	}
+	t := d.data[0]
+	d.data = d.data[1:]
+	return t
+}
+
+// itcID decodes an id tree. It keeps the inner nodes whose subtrees are not
+// complete yet on an explicit stack instead of recursing, so that the depth of
+// the input does not affect the depth of the call stack.
+func (d *binaryDecoder) itcID() *itcID {
+	// stack holds the left subtrees of the open inner nodes, or nil for inner
+	// nodes whose left subtree is not complete yet
+	var stack []*itcID
+	for {
+		var node *itcID
+		switch t := d.tag(); {
+		case d.err != nil:
+			return nil
+		case t == 0:
+			node = itcIDZero
+		case t == 1:
+			node = itcIDOne
+		case t == 2:
+			if len(stack) >= itcMaxDepth {
+				d.err = fmt.Errorf("vclock: binary ITC id is nested deeper than %d levels", itcMaxDepth)
+				return nil
+			}
+			stack = append(stack, nil)
+			continue
+		default:
+			d.err = fmt.Errorf("vclock: invalid id tag %d in binary ITC", t)
+			return nil
+		}
+
+		// close the inner nodes that node completes
+		for ; len(stack) > 0; stack = stack[:len(stack)-1] {
+			left := stack[len(stack)-1]
+			if left == nil {
+				stack[len(stack)-1] = node
+				break
+			}
+			node = itcIDNode(left, node)
+		}
+		if len(stack) == 0 {
+			return node
+		}
+	}
+}
+
+// itcEventFrame is an inner node of an event tree that is being decoded.
+type itcEventFrame struct {
+	n       uint64
+	left    *itcEvent
+	leftMin uint64
+}
+
+// itcEvent decodes an event tree with an explicit stack, see itcID. It tracks
+// the minimum of every decoded subtree, so that normalizing a node does not
+// have to walk its subtrees.
+func (d *binaryDecoder) itcEvent() *itcEvent {
+	var stack []itcEventFrame
+	for {
+		var node *itcEvent
+		switch t := d.tag(); {
+		case d.err != nil:
+			return nil
+		case t == 0:
+			node = itcLeaf(d.uvarint())
+		case t == 1:
+			if len(stack) >= itcMaxDepth {
+				d.err = fmt.Errorf("vclock: binary ITC event is nested deeper than %d levels", itcMaxDepth)
+				return nil
+			}
+			stack = append(stack, itcEventFrame{n: d.uvarint()})
+			continue
+		default:
+			d.err = fmt.Errorf("vclock: invalid event tag %d in binary ITC", t)
+			return nil
+		}
+		if d.err != nil {
+			return nil
+		}
+
+		// close the inner nodes that node completes
+		nodeMin := node.n
+		for ; len(stack) > 0; stack = stack[:len(stack)-1] {
+			f := &stack[len(stack)-1]
+			if f.left == nil {
+				f.left, f.leftMin = node, nodeMin
+				break
+			}
+			node, nodeMin = itcNodeMin(f.n, f.left, f.leftMin, node, nodeMin)
+		}
+		if len(stack) == 0 {
+			return node
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package vclock
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func mustEvent(t *testing.T, s ITC) ITC {
+	t.Helper()
+
+	e, err := s.Event()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestITCForkEventJoin(t *testing.T) {
+	seed := SeedITC()
+	if seed.String() != "(1, 0)" {
+		t.Fatalf("unexpected seed %s", seed)
+	}
+
+	a, b := seed.Fork()
+	if a.String() != "((1, 0), 0)" || b.String() != "((0, 1), 0)" {
+		t.Fatalf("unexpected fork %s and %s", a, b)
+	}
+
+	a = mustEvent(t, a)
+	b = mustEvent(t, b)
+	if a.String() != "((1, 0), (0, 1, 0))" || b.String() != "((0, 1), (0, 0, 1))" {
+		t.Fatalf("unexpected events %s and %s", a, b)
+	}
+	if a.Order(b) != Concurrent || !a.Compare(b, Concurrent) {
+		t.Fatalf("stamps not defined as Concurrent: %s | %s", a, b)
+	}
+
+	j := a.Join(b)
+	if j.String() != "(1, 1)" {
+		t.Fatalf("unexpected join %s", j)
+	}
+	if a.Order(j) != Descendant || j.Order(b) != Ancestor || j.Order(j) != Equal {
+		t.Fatalf("join not ordered after its parts: %s | %s | %s", a, b, j)
+	}
+}
+
+func TestITCPeek(t *testing.T) {
+	a := mustEvent(t, SeedITC())
+	p := a.Peek()
+
+	if p.Order(a) != Equal {
+		t.Fatalf("peek not defined as Equal: %s | %s", p, a)
+	}
+	if _, err := p.Event(); err != ErrAnonymousITC {
+		t.Fatalf("expected ErrAnonymousITC, got %v", err)
+	}
+	if _, err := (ITC{}).Event(); err != ErrAnonymousITC {
+		t.Fatalf("expected ErrAnonymousITC for the zero value, got %v", err)
+	}
+	if (ITC{}).Order(SeedITC()) != Equal {
+		t.Fatalf("zero value not defined as Equal to the seed")
+	}
+}
+
+// TestITCAgreesWithVClock runs a random execution with a changing set of
+// participants and checks that interval tree clocks and vector clocks order all
+// recorded events in the same way.
+func TestITCAgreesWithVClock(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	type participant struct {
+		name string
+		itc  ITC
+		vc   VClock
+	}
+	type message struct {
+		itc ITC
+		vc  VClock
+	}
+
+	var stamps []ITC
+	var clocks []VClock
+	record := func(p *participant) {
+		stamps = append(stamps, p.itc)
+		clocks = append(clocks, p.vc.Copy())
+	}
+
+	participants := []*participant{{name: "0", itc: SeedITC(), vc: New()}}
+	var inbox []message
+	next := 1
+
+	for step := 0; step < 300; step++ {
+		p := participants[r.Intn(len(participants))]
+
+		switch op := r.Intn(10); {
+		case op < 3:
+			// local event
+			p.itc = mustEvent(t, p.itc)
+			p.vc.Tick(p.name)
+			record(p)
+		case op < 6:
+			// send a message
+			p.itc = mustEvent(t, p.itc)
+			p.vc.Tick(p.name)
+			record(p)
+			inbox = append(inbox, message{itc: p.itc.Peek(), vc: p.vc.Copy()})
+		case op < 8 && len(inbox) > 0:
+			// receive a random message
+			i := r.Intn(len(inbox))
+			m := inbox[i]
+			inbox = append(inbox[:i], inbox[i+1:]...)
+
+			p.itc = mustEvent(t, p.itc.Join(m.itc))
+			p.vc.Merge(m.vc)
+			p.vc.Tick(p.name)
+			record(p)
+		case op < 9 && len(participants) < 8:
+			// a new participant joins
+			var forked ITC
+			p.itc, forked = p.itc.Fork()
+			n := &participant{name: strconv.Itoa(next), itc: forked, vc: p.vc.Copy()}
+			next++
+			participants = append(participants, n)
+		case len(participants) > 1:
+			// the participant retires and hands its id to another one
+			var q *participant
+			for q == nil || q == p {
+				q = participants[r.Intn(len(participants))]
+			}
+			q.itc = mustEvent(t, q.itc.Join(p.itc))
+			q.vc.Merge(p.vc)
+			q.vc.Tick(q.name)
+			record(q)
+
+			for i := range participants {
+				if participants[i] == p {
+					participants = append(participants[:i], participants[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+
+	for i := range stamps {
+		for j := range stamps {
+			if stamps[i].Order(stamps[j]) != clocks[i].Order(clocks[j]) {
+				t.Fatalf("ITC order %d of %s and %s does not agree with VClock order %d of %s and %s",
+					stamps[i].Order(stamps[j]), stamps[i], stamps[j],
+					clocks[i].Order(clocks[j]), clocks[i].ReturnVCString(), clocks[j].ReturnVCString())
+			}
+		}
+	}
+
+	// all ids have been given back, so joining the participants gives the seed id
+	all := ITC{}
+	for _, p := range participants {
+		all = all.Join(p.itc)
+	}
+	if !all.ids().isOne() {
+		t.Fatalf("joined id is not the whole id space: %s", all)
+	}
+}
+
+func TestITCBinaryRoundTrip(t *testing.T) {
+	a, b := SeedITC().Fork()
+	b, c := b.Fork()
+	a = mustEvent(t, a)
+	c = mustEvent(t, mustEvent(t, c))
+	b = mustEvent(t, b.Join(c.Peek()))
+
+	for _, s := range []ITC{SeedITC(), {}, a, b, c, a.Join(b)} {
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded ITC
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.String() != s.String() {
+			t.Fatalf("decoded %s not the same as encoded %s", decoded, s)
+		}
+
+		for i := 0; i < len(data); i++ {
+			if err := decoded.UnmarshalBinary(data[:i]); err == nil {
+				t.Fatalf("expected error for truncated stamp %s of %d bytes", s, i)
+			}
+		}
+	}
+}
+
+func TestITCBinaryRoundTripManyForks(t *testing.T) {
+	// the seed hands out an identity to a new participant in every round, so
+	// its id tree gets one level deeper per fork
+	s := SeedITC()
+	for i := 0; i < 520; i++ {
+		s, _ = s.Fork()
+		s = mustEvent(t, s)
+	}
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ITC
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != s.String() || decoded.Order(s) != Equal {
+		t.Fatalf("decoded %s not the same as encoded %s", decoded, s)
+	}
+}
+
+func TestITCMarshalTooDeep(t *testing.T) {
+	id := itcIDOne
+	event := itcLeaf(1)
+	for i := 0; i <= itcMaxDepth; i++ {
+		id = itcIDNode(itcIDZero, id)
+		event = &itcEvent{left: itcLeaf(0), right: event}
+	}
+
+	for name, s := range map[string]ITC{"id": {id: id}, "event": {event: event}} {
+		if _, err := s.MarshalBinary(); err == nil || !strings.Contains(err.Error(), "nested deeper") {
+			t.Fatalf("%s: expected depth error, got %v", name, err)
+		}
+	}
+}
+
+func TestITCUnmarshalDeeplyNested(t *testing.T) {
+	inputs := map[string][]byte{
+		"id":    deeplyNested(2, 10000000, nil),
+		"event": deeplyNested(1, 10000000, []byte{1}),
+	}
+
+	for name, data := range inputs {
+		var decoded ITC
+		err := decoded.UnmarshalBinary(data)
+		if err == nil || !strings.Contains(err.Error(), "nested deeper") {
+			t.Fatalf("%s: expected depth error, got %v", name, err)
+		}
+	}
+}
+
+// deeplyNested returns a binary ITC that starts with the given id, if any,
+// followed by depth nested nodes with the given tag.
+func deeplyNested(tag byte, depth int, id []byte) []byte {
+	data := append([]byte{itcMagic, binaryVersion}, id...)
+	for i := 0; i < depth; i++ {
+		data = append(data, tag)
+		if tag == 1 {
+			// base counter of the event node
+			data = append(data, 0)
+		}
+	}
+	return data
+}
+
+func TestITCUnmarshalInvalid(t *testing.T) {
+	inputs := map[string][]byte{
+		"magic":     {0x00, binaryVersion, 1, 0, 0},
+		"version":   {itcMagic, 99, 1, 0, 0},
+		"id tag":    {itcMagic, binaryVersion, 3, 0, 0},
+		"event tag": {itcMagic, binaryVersion, 1, 2, 0},
+		"trailing":  {itcMagic, binaryVersion, 1, 0, 0, 0},
+	}
+
+	for name, data := range inputs {
+		var decoded ITC
+		if err := decoded.UnmarshalBinary(data); err == nil {
+			t.Fatalf("%s: expected error, got %s", name, decoded)
+		}
+	}
+}
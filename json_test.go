@@ -0,0 +1,112 @@
+package vclock
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJSONSorted(t *testing.T) {
+	n := New()
+	n.Set("d", 32)
+	n.Set("b", 1)
+	n.Set("a", 4)
+	n.Set("c", 8)
+
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\"a\":4,\"b\":1,\"c\":8,\"d\":32}"
+	if string(b) != expected {
+		t.Fatalf("JSON %s not the same as expected %s", b, expected)
+	}
+}
+
+func TestMarshalJSONNil(t *testing.T) {
+	var n VClock
+
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "null" {
+		t.Fatalf("JSON %s not the same as expected null", b)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	n := genVClock(100)
+
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded VClock
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !n.Compare(decoded, Equal) {
+		failComparison(t, "decoded not the same as encoded enc = %s | dec = %s", n, decoded)
+	}
+}
+
+func TestUnmarshalJSONFromVCString(t *testing.T) {
+	n := New()
+	n.Set("a", 1)
+	n.Set("b", 2)
+	n.Set("c", 18446744073709551615)
+
+	var decoded VClock
+	if err := json.Unmarshal([]byte(n.ReturnVCString()), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !n.Compare(decoded, Equal) {
+		failComparison(t, "decoded not the same as encoded enc = %s | dec = %s", n, decoded)
+	}
+}
+
+func TestUnmarshalJSONNull(t *testing.T) {
+	n := New()
+	n.Set("a", 1)
+
+	if err := n.UnmarshalJSON([]byte("null")); err != nil {
+		t.Fatal(err)
+	}
+
+	if ticks, ok := n.FindTicks("a"); !ok || ticks != 1 {
+		t.Fatalf("null modified the clock: %s", n.ReturnVCString())
+	}
+}
+
+func TestUnmarshalJSONInvalid(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"{\"a\":-1}", "negative"},
+		{"{\"a\":1.5}", "not an integer"},
+		{"{\"a\":1e3}", "not an integer"},
+		{"{\"a\":18446744073709551616}", "out of range"},
+		{"{\"a\":\"1\"}", "not a number"},
+		{"{\"a\":null}", "not a number"},
+		{"[1, 2]", "cannot decode"},
+		{"{\"a\":1", "cannot decode"},
+	}
+
+	for _, test := range tests {
+		var decoded VClock
+		err := decoded.UnmarshalJSON([]byte(test.input))
+		if err == nil {
+			t.Fatalf("expected error for %s, got %s", test.input, decoded.ReturnVCString())
+		}
+		if !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("expected error for %s to contain %q, got %q", test.input, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package vclock
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Lamport is a scalar logical clock as described by Lamport in "Time, Clocks,
+// and the Ordering of Events in a Distributed System"
+// (https://doi.org/10.1145/359545.359563). It is much cheaper than a VClock,
+// but it cannot detect concurrency: if an event happened before another, its
+// Lamport time is smaller, but a smaller Lamport time does not imply that the
+// event happened before.
+//
+// The zero value is a clock at time 0 ready to use. Just like VClock, a Lamport
+// clock is not safe for concurrent use.
+type Lamport struct {
+	time uint64
+}
+
+// NewLamport returns a new Lamport clock at the given time.
+func NewLamport(time uint64) *Lamport {
+	return &Lamport{time: time}
+}
+
+// Time returns the current time of the clock.
+func (l *Lamport) Time() uint64 {
+	return l.time
+}
+
+// Copy returns a copy of the clock.
+func (l *Lamport) Copy() *Lamport {
+	return &Lamport{time: l.time}
+}
+
+// Tick increments the clock by 1. The process id is ignored, it is only there
+// so that Lamport implements the Clock interface.
+func (l *Lamport) Tick(id string) {
+	l.time++
+}
+
+// Merge sets the clock to the maximum of its own time and the time of other.
+// Just like with VClock, a receive event is a Merge followed by a Tick.
+func (l *Lamport) Merge(other *Lamport) {
+	if other.time > l.time {
+		l.time = other.time
+	}
+}
+
+// Order determines the relationship between two Lamport clocks in terms of the
+// Condition constants. It returns Equal if both clocks have the same time,
+// Ancestor if other has a smaller time, and Descendant if other has a greater
+// time. Since Lamport clocks totally order events, Order never returns
+// Concurrent; events that VClock.Order reports as Concurrent are ordered
+// arbitrarily, or reported as Equal if their times are the same.
+func (l *Lamport) Order(other *Lamport) Condition {
+	switch {
+	case l.time == other.time:
+		return Equal
+	case l.time > other.time:
+		return Ancestor
+	default:
+		return Descendant
+	}
+}
+
+// Compare takes another clock and determines if it is Equal, an Ancestor, or a
+// Descendant of the callee according to Order. The condition may be ORed, see
+// VClock.Compare.
+func (l *Lamport) Compare(other *Lamport, cond Condition) bool {
+	return l.Order(other)&cond != 0
+}
+
+// The binary encoding of a Lamport clock is
+//
+//	magic (1 byte) | version (1 byte) | time (uvarint)
+const lamportMagic byte = 0xca
+
+// Bytes returns the binary encoding of the clock.
+func (l *Lamport) Bytes() []byte {
+	b := make([]byte, 0, 2+binary.MaxVarintLen64)
+	b = append(b, lamportMagic, binaryVersion)
+	return binary.AppendUvarint(b, l.time)
+}
+
+// LamportFromBytes decodes a Lamport clock from a byte slice returned by Bytes.
+func LamportFromBytes(data []byte) (*Lamport, error) {
+	if len(data) < 2 {
+		return nil, errTruncated
+	}
+	if data[0] != lamportMagic {
+		return nil, fmt.Errorf("vclock: invalid magic byte 0x%02x in binary Lamport clock", data[0])
+	}
+	if data[1] != binaryVersion {
+		return nil, fmt.Errorf("vclock: unsupported binary Lamport clock version %d", data[1])
+	}
+
+	d := binaryDecoder{data: data[2:]}
+	time := d.uvarint()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(d.data) > 0 {
+		return nil, fmt.Errorf("vclock: %d trailing bytes after binary Lamport clock", len(d.data))
+	}
+	return &Lamport{time: time}, nil
+}
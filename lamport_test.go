@@ -0,0 +1,143 @@
+package vclock
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestLamportTickMerge(t *testing.T) {
+	var l1 Lamport
+	l2 := NewLamport(5)
+
+	l1.Tick("a")
+	if l1.Time() != 1 {
+		t.Fatalf("Tick value did not increment: %d", l1.Time())
+	}
+
+	l1.Merge(l2)
+	l1.Tick("a")
+	if l1.Time() != 6 {
+		t.Fatalf("Merge not as expected: %d", l1.Time())
+	}
+
+	l2.Merge(&l1)
+	if l2.Time() != 6 {
+		t.Fatalf("Merge not as expected: %d", l2.Time())
+	}
+}
+
+func TestLamportOrder(t *testing.T) {
+	l1 := NewLamport(1)
+	l2 := NewLamport(2)
+
+	if l1.Order(l2) != Descendant || !l1.Compare(l2, Descendant) {
+		t.Fatalf("Clocks not defined as Descendant: %d | %d", l1.Time(), l2.Time())
+	}
+	if l2.Order(l1) != Ancestor {
+		t.Fatalf("Clocks not defined as Ancestor: %d | %d", l2.Time(), l1.Time())
+	}
+	if l1.Order(l1.Copy()) != Equal {
+		t.Fatalf("Clocks not defined as Equal: %d | %d", l1.Time(), l1.Time())
+	}
+}
+
+func TestLamportEncodeDecode(t *testing.T) {
+	for _, time := range []uint64{0, 1, 300, 1 << 63} {
+		l := NewLamport(time)
+
+		decoded, err := LamportFromBytes(l.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Time() != time {
+			t.Fatalf("decoded %d not the same as encoded %d", decoded.Time(), time)
+		}
+	}
+
+	invalid := [][]byte{
+		{},
+		{lamportMagic, binaryVersion},
+		{binaryMagic, binaryVersion, 1},
+		{lamportMagic, 99, 1},
+		{lamportMagic, binaryVersion, 1, 1},
+	}
+	for _, data := range invalid {
+		if _, err := LamportFromBytes(data); err == nil {
+			t.Fatalf("expected error for %x", data)
+		}
+	}
+}
+
+// exchange sends a message from sender to receiver using only the Clock
+// interface and returns the clocks of the send and the receive event.
+func exchange[C Clock[C]](sender, receiver C, senderID, receiverID string, decode func([]byte) (C, error)) (C, C, error) {
+	sender.Tick(senderID)
+	sent, err := decode(sender.Bytes())
+	if err != nil {
+		return sent, sent, err
+	}
+
+	receiver.Merge(sent)
+	receiver.Tick(receiverID)
+	received, err := decode(receiver.Bytes())
+	return sent, received, err
+}
+
+func TestClockInterface(t *testing.T) {
+	vcSent, vcReceived, err := exchange(New(), New(), "a", "b", FromBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vcSent.Order(vcReceived) != Descendant {
+		failComparison(t, "Clocks not defined as Descendant: n1 = %s | n2 = %s", vcSent, vcReceived)
+	}
+
+	lSent, lReceived, err := exchange(&Lamport{}, &Lamport{}, "a", "b", LamportFromBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lSent.Order(lReceived) != Descendant {
+		t.Fatalf("Clocks not defined as Descendant: %d | %d", lSent.Time(), lReceived.Time())
+	}
+}
+
+// TestLamportConsistentWithVClock checks the clock condition: whenever vector
+// clocks order two events, Lamport clocks order them in the same way.
+func TestLamportConsistentWithVClock(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ids := []string{"a", "b", "c"}
+
+	vcs := map[string]VClock{}
+	ls := map[string]*Lamport{}
+	for _, id := range ids {
+		vcs[id] = New()
+		ls[id] = &Lamport{}
+	}
+
+	var vcEvents []VClock
+	var lEvents []*Lamport
+	for i := 0; i < 200; i++ {
+		id := ids[r.Intn(len(ids))]
+		if r.Intn(2) == 0 {
+			from := ids[r.Intn(len(ids))]
+			vcs[id].Merge(vcs[from])
+			ls[id].Merge(ls[from])
+		}
+		vcs[id].Tick(id)
+		ls[id].Tick(id)
+
+		vcEvents = append(vcEvents, vcs[id].Copy())
+		lEvents = append(lEvents, ls[id].Copy())
+	}
+
+	for i := range vcEvents {
+		for j := range vcEvents {
+			order := vcEvents[i].Order(vcEvents[j])
+			if order != Concurrent && lEvents[i].Order(lEvents[j]) != order {
+				t.Fatalf("Lamport order %d of %d and %d does not agree with VClock order %d of %s and %s",
+					lEvents[i].Order(lEvents[j]), lEvents[i].Time(), lEvents[j].Time(),
+					order, vcEvents[i].ReturnVCString(), vcEvents[j].ReturnVCString())
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package logger implements a vector clock logger in the style of the govec
+// package of GoVector. A Logger keeps the vector clock of a single process,
+// attaches it to outgoing messages, merges it from incoming messages, and
+// writes every event to a log that ShiViz (https://bestchai.bitbucket.io/shiviz/)
+// can visualize.
+//
+// Every event is logged as two lines: the process id followed by the vector
+// clock of the event, and the message describing the event, e.g.:
+//
+//	client {"client":2, "server":1}
+//	Sending request
+//
+// Line breaks in messages are written as \n and \r, so that every event takes
+// exactly two lines. Use the following regular expression to parse such logs
+// in ShiViz:
+//
+//	(?<host>\S*) (?<clock>{.*})\n(?<event>.*)
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+
+	"git.tu-berlin.de/mcc-fred/vclock"
+)
+
+// messageEscaper replaces the line breaks in messages, which would otherwise
+// break the two-line format of the log.
+var messageEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`)
+
+// errTruncated is returned by UnpackReceive for buffers that end in the middle
+// of the header.
+var errTruncated = errors.New("logger: message is truncated")
+
+// Config configures a Logger.
+type Config struct {
+	// EncodingStrategy encodes the payloads passed to PrepareSend. It
+	// defaults to json.Marshal.
+	EncodingStrategy func(v interface{}) ([]byte, error)
+	// DecodingStrategy decodes the payloads passed to UnpackReceive. It
+	// defaults to json.Unmarshal.
+	DecodingStrategy func(data []byte, v interface{}) error
+}
+
+// Logger logs the events of a single process together with their vector
+// clocks.
+//
+// A Logger is safe for concurrent use by multiple goroutines.
+type Logger struct {
+	mu  sync.Mutex
+	pid string
+	vc  vclock.VClock
+	w   io.Writer
+	cfg Config
+}
+
+// New returns a new logger for the process with the given id that writes its
+// log to w. The clock of the process starts out empty. The id must not be
+// empty or contain white space, which would break the format of the log.
+func New(pid string, w io.Writer, cfg Config) (*Logger, error) {
+	if pid == "" || strings.IndexFunc(pid, unicode.IsSpace) >= 0 {
+		return nil, fmt.Errorf("logger: invalid process id %q", pid)
+	}
+	if cfg.EncodingStrategy == nil {
+		cfg.EncodingStrategy = json.Marshal
+	}
+	if cfg.DecodingStrategy == nil {
+		cfg.DecodingStrategy = json.Unmarshal
+	}
+	return &Logger{pid: pid, vc: vclock.New(), w: w, cfg: cfg}, nil
+}
+
+// PrepareSend records a send event, logs it with the given message, and
+// returns a buffer to send to another process. The buffer holds the id of the
+// process, its vector clock after the send event, and the encoded payload.
+func (l *Logger) PrepareSend(msg string, payload interface{}) ([]byte, error) {
+	data, err := l.cfg.EncodingStrategy(payload)
+	if err != nil {
+		return nil, fmt.Errorf("logger: cannot encode payload: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.vc.Tick(l.pid)
+
+	buf := binary.AppendUvarint(nil, uint64(len(l.pid)))
+	buf = append(buf, l.pid...)
+	buf, err = l.vc.AppendBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, data...)
+
+	return buf, l.log(msg)
+}
+
+// UnpackReceive records the receive event of a buffer returned by PrepareSend
+// of another process and logs it with the given message. It merges the clock
+// of the sender into the clock of the process and decodes the payload into
+// unpack.
+func (l *Logger) UnpackReceive(msg string, buf []byte, unpack interface{}) error {
+	r := bytes.NewReader(buf)
+
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return errTruncated
+	}
+	if n > uint64(r.Len()) {
+		return errTruncated
+	}
+	if _, err := r.Seek(int64(n), io.SeekCurrent); err != nil {
+		return err
+	}
+
+	var remote vclock.VClock
+	if _, err := remote.ReadFrom(r); err != nil {
+		return fmt.Errorf("logger: cannot decode clock: %w", err)
+	}
+
+	if err := l.cfg.DecodingStrategy(buf[len(buf)-r.Len():], unpack); err != nil {
+		return fmt.Errorf("logger: cannot decode payload: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.vc.Merge(remote)
+	l.vc.Tick(l.pid)
+	return l.log(msg)
+}
+
+// LogLocalEvent records a local event and logs it with the given message.
+func (l *Logger) LogLocalEvent(msg string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.vc.Tick(l.pid)
+	return l.log(msg)
+}
+
+// GetCurrentVC returns a copy of the current vector clock of the process.
+func (l *Logger) GetCurrentVC() vclock.VClock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.vc.Copy()
+}
+
+// log writes the current event to the log, l.mu must be held.
+func (l *Logger) log(msg string) error {
+	if _, err := fmt.Fprintf(l.w, "%s %s\n%s\n", l.pid, l.vc.ReturnVCString(), messageEscaper.Replace(msg)); err != nil {
+		return fmt.Errorf("logger: cannot write log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func mustNew(t *testing.T, pid string, w io.Writer, cfg Config) *Logger {
+	t.Helper()
+
+	l, err := New(pid, w, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+type request struct {
+	Key   string
+	Value int
+}
+
+func TestSendReceive(t *testing.T) {
+	var clientLog, serverLog bytes.Buffer
+	client := mustNew(t, "client", &clientLog, Config{})
+	server := mustNew(t, "server", &serverLog, Config{})
+
+	if err := server.LogLocalEvent("Starting up"); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := client.PrepareSend("Sending request", request{Key: "x", Value: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req request
+	if err := server.UnpackReceive("Received request", buf, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req != (request{Key: "x", Value: 42}) {
+		t.Fatalf("unexpected payload %+v", req)
+	}
+
+	if s := server.GetCurrentVC().ReturnVCString(); s != "{\"client\":1, \"server\":2}" {
+		t.Fatalf("unexpected clock %s", s)
+	}
+
+	expected := "server {\"server\":1}\nStarting up\n" +
+		"server {\"client\":1, \"server\":2}\nReceived request\n"
+	if serverLog.String() != expected {
+		t.Fatalf("unexpected log:\n%s", serverLog.String())
+	}
+	if clientLog.String() != "client {\"client\":1}\nSending request\n" {
+		t.Fatalf("unexpected log:\n%s", clientLog.String())
+	}
+}
+
+func TestCustomStrategies(t *testing.T) {
+	var log bytes.Buffer
+	cfg := Config{
+		EncodingStrategy: func(v interface{}) ([]byte, error) {
+			return []byte(v.(string)), nil
+		},
+		DecodingStrategy: func(data []byte, v interface{}) error {
+			*v.(*string) = string(data)
+			return nil
+		},
+	}
+	a := mustNew(t, "a", &log, cfg)
+	b := mustNew(t, "b", &log, cfg)
+
+	buf, err := a.PrepareSend("send", "raw payload")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload string
+	if err := b.UnpackReceive("receive", buf, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload != "raw payload" {
+		t.Fatalf("unexpected payload %q", payload)
+	}
+}
+
+func TestUnpackReceiveErrors(t *testing.T) {
+	var log bytes.Buffer
+	a := mustNew(t, "a", &log, Config{})
+	b := mustNew(t, "b", &log, Config{})
+
+	buf, err := a.PrepareSend("send", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v int
+	for i := 0; i < len(buf)-1; i++ {
+		if err := b.UnpackReceive("receive", buf[:i], &v); err == nil {
+			t.Fatalf("expected error for truncated buffer of length %d", i)
+		}
+	}
+
+	if err := b.UnpackReceive("receive", buf, new(string)); err == nil {
+		t.Fatalf("expected error for payload of the wrong type")
+	}
+
+	failing := mustNew(t, "c", failingWriter{}, Config{})
+	if err := failing.LogLocalEvent("event"); err == nil {
+		t.Fatalf("expected error for failing writer")
+	}
+
+	// nothing was received, so the clock of b is still empty
+	if len(b.GetCurrentVC()) != 0 {
+		t.Fatalf("clock changed by failed receive: %s", b.GetCurrentVC().ReturnVCString())
+	}
+}
+
+func TestInvalidProcessID(t *testing.T) {
+	for _, pid := range []string{"", "my client", "a\nb", "a\tb"} {
+		if _, err := New(pid, io.Discard, Config{}); err == nil {
+			t.Fatalf("expected error for process id %q", pid)
+		}
+	}
+}
+
+func TestMultiLineMessage(t *testing.T) {
+	var log bytes.Buffer
+	l := mustNew(t, "a", &log, Config{})
+
+	if err := l.LogLocalEvent("first line\nsecond line\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	if log.String() != "a {\"a\":1}\nfirst line\\nsecond line\\r\\n\n" {
+		t.Fatalf("unexpected log:\n%s", log.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
@@ -0,0 +1,159 @@
+package vclock
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// MatrixClock is a matrix clock of a single process. Besides the vector clock
+// of the process itself, it keeps one row for every other process, which
+// holds what the process knows about the vector clock of that process. This
+// allows it to tell when every process has seen an event, e.g., to garbage
+// collect tombstones once a delete is causally stable.
+//
+// Just like VClock, a MatrixClock is not safe for concurrent use.
+type MatrixClock struct {
+	id   string
+	rows map[string]VClock
+}
+
+// matrixClockJSON is the JSON representation of a MatrixClock.
+type matrixClockJSON struct {
+	ID   string            `json:"id"`
+	Rows map[string]VClock `json:"rows"`
+}
+
+// NewMatrixClock returns a new matrix clock for the process with the given id.
+// peers are the other processes of the system. The clock also learns about
+// processes from the messages it receives, but a process only counts towards
+// StableFrontier once the clock knows about it.
+func NewMatrixClock(id string, peers ...string) *MatrixClock {
+	m := &MatrixClock{id: id, rows: map[string]VClock{id: New()}}
+	for _, peer := range peers {
+		if _, ok := m.rows[peer]; !ok {
+			m.rows[peer] = New()
+		}
+	}
+	return m
+}
+
+// ID returns the id of the process that owns the clock.
+func (m *MatrixClock) ID() string {
+	return m.id
+}
+
+// Peers returns the ids of all processes the clock knows about, including its
+// owner, in ascending order.
+func (m *MatrixClock) Peers() []string {
+	peers := make([]string, 0, len(m.rows))
+	for id := range m.rows {
+		peers = append(peers, id)
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// Clock returns a copy of the vector clock of the owner of the clock.
+func (m *MatrixClock) Clock() VClock {
+	return m.rows[m.id].Copy()
+}
+
+// Row returns a copy of what the owner of the clock knows about the vector
+// clock of the process with the given id.
+func (m *MatrixClock) Row(id string) VClock {
+	return m.rows[id].Copy()
+}
+
+// Copy returns a deep copy of the matrix clock.
+func (m *MatrixClock) Copy() *MatrixClock {
+	cp := &MatrixClock{id: m.id, rows: make(map[string]VClock, len(m.rows))}
+	for id, row := range m.rows {
+		cp.rows[id] = row.Copy()
+	}
+	return cp
+}
+
+// Tick records a local event of the owner of the clock.
+func (m *MatrixClock) Tick() {
+	m.rows[m.id].Tick(m.id)
+}
+
+// Send records a send event and returns a copy of the resulting matrix clock
+// to attach to the outgoing message.
+func (m *MatrixClock) Send() *MatrixClock {
+	m.Tick()
+	return m.Copy()
+}
+
+// Receive records the receive event of a message that carried the matrix clock
+// msg of its sender. The owner learns everything the sender knew, both about
+// events and about what other processes know.
+func (m *MatrixClock) Receive(msg *MatrixClock) {
+	for id, row := range msg.rows {
+		if _, ok := m.rows[id]; !ok {
+			m.rows[id] = New()
+		}
+		m.rows[id].Merge(row)
+	}
+
+	m.rows[m.id].Merge(msg.rows[msg.id])
+	m.Tick()
+}
+
+// StableFrontier returns the element-wise minimum of all rows of the clock,
+// i.e., for every process id the number of its events that every known process
+// is known to have seen. Entries with a clock value of 0 are omitted.
+func (m *MatrixClock) StableFrontier() VClock {
+	frontier := m.rows[m.id].Copy()
+	for _, row := range m.rows {
+		for id, ticks := range frontier {
+			if row[id] < ticks {
+				frontier[id] = row[id]
+			}
+		}
+	}
+	frontier.Normalize()
+	return frontier
+}
+
+// IsStable reports whether every known process is known to have seen the given
+// event, the ticks-th event of the process with the given id.
+func (m *MatrixClock) IsStable(id string, ticks uint64) bool {
+	for _, row := range m.rows {
+		if row[id] < ticks {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (m *MatrixClock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(matrixClockJSON{ID: m.id, Rows: m.rows})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. The callee is
+// replaced by the decoded matrix clock.
+func (m *MatrixClock) UnmarshalJSON(data []byte) error {
+	var j matrixClockJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return fmt.Errorf("vclock: cannot decode JSON matrix clock: %w", err)
+	}
+
+	if j.Rows == nil {
+		j.Rows = map[string]VClock{}
+	}
+	for id, row := range j.Rows {
+		if row == nil {
+			j.Rows[id] = New()
+		}
+	}
+	if _, ok := j.Rows[j.ID]; !ok {
+		j.Rows[j.ID] = New()
+	}
+
+	m.id = j.ID
+	m.rows = j.Rows
+	return nil
+}
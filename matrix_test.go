@@ -0,0 +1,95 @@
+package vclock
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMatrixClockStableFrontier(t *testing.T) {
+	a := NewMatrixClock("a", "b", "c")
+	b := NewMatrixClock("b", "a", "c")
+	c := NewMatrixClock("c", "a", "b")
+
+	// a deletes something, the tombstone is event a:1
+	a.Tick()
+	if a.IsStable("a", 1) {
+		t.Fatalf("event is stable before anyone else has seen it")
+	}
+
+	// a tells b, b tells c
+	b.Receive(a.Send())
+	c.Receive(b.Send())
+
+	// c knows that a and b have seen the events of a, but neither a nor b
+	// know that c has seen them
+	if f := c.StableFrontier(); f.ReturnVCString() != "{\"a\":2}" {
+		t.Fatalf("unexpected frontier at c %s", f.ReturnVCString())
+	}
+	if !c.IsStable("a", 1) || c.IsStable("b", 1) {
+		t.Fatalf("unexpected stability at c, frontier %s", c.StableFrontier().ReturnVCString())
+	}
+	if a.IsStable("a", 1) || b.IsStable("a", 1) {
+		t.Fatalf("event is stable before everyone is known to have seen it")
+	}
+
+	// a learns about it once it hears back from c
+	a.Receive(c.Send())
+
+	if f := a.StableFrontier(); f.ReturnVCString() != "{\"a\":2, \"b\":2}" {
+		t.Fatalf("unexpected frontier at a %s", f.ReturnVCString())
+	}
+	if !a.IsStable("a", 1) || a.IsStable("a", 3) {
+		t.Fatalf("unexpected stability at a, frontier %s", a.StableFrontier().ReturnVCString())
+	}
+}
+
+func TestMatrixClockReceive(t *testing.T) {
+	a := NewMatrixClock("a")
+	b := NewMatrixClock("b")
+
+	a.Tick()
+	b.Receive(a.Send())
+
+	if b.Clock().ReturnVCString() != "{\"a\":2, \"b\":1}" {
+		t.Fatalf("unexpected clock %s", b.Clock().ReturnVCString())
+	}
+	if b.Row("a").ReturnVCString() != "{\"a\":2}" {
+		t.Fatalf("unexpected row %s", b.Row("a").ReturnVCString())
+	}
+	if strings.Join(b.Peers(), ",") != "a,b" {
+		t.Fatalf("unexpected peers %v", b.Peers())
+	}
+
+	// rows are copies
+	b.Row("a").Tick("a")
+	b.Clock().Tick("a")
+	if b.Clock().ReturnVCString() != "{\"a\":2, \"b\":1}" {
+		t.Fatalf("clock changed through a copy %s", b.Clock().ReturnVCString())
+	}
+}
+
+func TestMatrixClockJSON(t *testing.T) {
+	a := NewMatrixClock("a", "b")
+	a.Tick()
+
+	data, err := json.Marshal(a.Send())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var msg MatrixClock
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.ID() != "a" || msg.Clock().ReturnVCString() != "{\"a\":2}" || strings.Join(msg.Peers(), ",") != "a,b" {
+		t.Fatalf("decoded matrix clock not the same as encoded: %s", data)
+	}
+
+	b := NewMatrixClock("b")
+	b.Receive(&msg)
+	if b.Clock().ReturnVCString() != "{\"a\":2, \"b\":1}" {
+		t.Fatalf("unexpected clock %s", b.Clock().ReturnVCString())
+	}
+}
@@ -0,0 +1,166 @@
+package vclock
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is returned by CausalQueue.Push if a message cannot be delivered
+// yet and the queue already buffers the maximum number of messages.
+var ErrQueueFull = errors.New("vclock: causal queue is full")
+
+// ErrDuplicateMessage is returned by CausalQueue.Push for a message that has
+// already been delivered or is already buffered.
+var ErrDuplicateMessage = errors.New("vclock: message already delivered or buffered")
+
+// Message is a message of a causal broadcast together with the id of its
+// sender and the vector clock of its send event.
+type Message[T any] struct {
+	Sender  string
+	Clock   VClock
+	Payload T
+}
+
+// Waiting describes a buffered message of a CausalQueue and the messages it
+// waits for.
+type Waiting struct {
+	// Sender is the id of the sender of the buffered message.
+	Sender string
+	// Clock is the vector clock of the buffered message.
+	Clock VClock
+	// Missing holds, for every process id, the range of clock values of the
+	// messages that must be delivered before the buffered message can be, see
+	// VClock.MissingDeps.
+	Missing map[string]CounterRange
+}
+
+// CausalQueue implements causal delivery of broadcast messages following the
+// rule of Birman, Schiper, and Stephenson: a message from sender is delivered
+// once all messages of sender before it and all messages that sender had
+// delivered before sending it have been delivered, see VClock.Deliverable.
+// Messages that arrive too early are buffered until their predecessors arrive.
+//
+// The clock of every message must count the broadcasts of each process only,
+// i.e., the sender ticks its own entry once per broadcast and does not tick
+// on delivery. A process pushes its own broadcasts to its queue as well, they
+// are delivered right away.
+//
+// A CausalQueue is safe for concurrent use by multiple goroutines.
+type CausalQueue[T any] struct {
+	mu          sync.Mutex
+	delivered   VClock
+	pending     []Message[T]
+	maxBuffered int
+	deliver     func(Message[T])
+}
+
+// NewCausalQueue returns a new causal queue that calls deliver for every
+// message in causal order. delivered is the clock of the messages that have
+// already been delivered, it may be nil to start from scratch. If maxBuffered
+// is greater than 0, at most maxBuffered messages are buffered.
+//
+// deliver is called while the queue is locked, so that messages are delivered
+// one at a time in causal order. It must not call methods of the queue.
+func NewCausalQueue[T any](delivered VClock, maxBuffered int, deliver func(Message[T])) *CausalQueue[T] {
+	if delivered == nil {
+		delivered = New()
+	}
+	return &CausalQueue[T]{
+		delivered:   delivered.Copy(),
+		maxBuffered: maxBuffered,
+		deliver:     deliver,
+	}
+}
+
+// Push hands a message received from sender with the given clock to the
+// queue. The message and any buffered messages that depend on it are
+// delivered right away if possible, otherwise the message is buffered. Push
+// returns ErrDuplicateMessage if the message has already been delivered or
+// buffered, and ErrQueueFull if it would have to be buffered but the buffer
+// is full.
+func (q *CausalQueue[T]) Push(sender string, clock VClock, payload T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if clock[sender] <= q.delivered[sender] {
+		return ErrDuplicateMessage
+	}
+	for _, m := range q.pending {
+		if m.Sender == sender && m.Clock[sender] == clock[sender] {
+			return ErrDuplicateMessage
+		}
+	}
+
+	msg := Message[T]{Sender: sender, Clock: clock.Copy(), Payload: payload}
+	if !q.delivered.Deliverable(msg.Sender, msg.Clock) {
+		if q.maxBuffered > 0 && len(q.pending) >= q.maxBuffered {
+			return ErrQueueFull
+		}
+		q.pending = append(q.pending, msg)
+		return nil
+	}
+
+	q.deliverMessage(msg)
+	q.deliverPending()
+	return nil
+}
+
+// Len returns the number of buffered messages, i.e., the messages that are
+// stuck waiting for their causal predecessors.
+func (q *CausalQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+// Delivered returns a copy of the clock of all messages delivered so far.
+func (q *CausalQueue[T]) Delivered() VClock {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.delivered.Copy()
+}
+
+// Waiting returns the buffered messages in the order in which they arrived,
+// together with the dependencies each of them is still missing.
+func (q *CausalQueue[T]) Waiting() []Waiting {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	waiting := make([]Waiting, 0, len(q.pending))
+	for _, m := range q.pending {
+		waiting = append(waiting, Waiting{
+			Sender:  m.Sender,
+			Clock:   m.Clock.Copy(),
+			Missing: q.delivered.MissingDeps(m.Sender, m.Clock),
+		})
+	}
+	return waiting
+}
+
+// deliverMessage delivers msg, q.mu must be held.
+func (q *CausalQueue[T]) deliverMessage(msg Message[T]) {
+	q.delivered.Set(msg.Sender, msg.Clock[msg.Sender])
+	if q.deliver != nil {
+		q.deliver(msg)
+	}
+}
+
+// deliverPending delivers buffered messages until none of them is
+// deliverable, q.mu must be held.
+func (q *CausalQueue[T]) deliverPending() {
+	for progress := true; progress; {
+		progress = false
+		for i := 0; i < len(q.pending); i++ {
+			msg := q.pending[i]
+			if !q.delivered.Deliverable(msg.Sender, msg.Clock) {
+				continue
+			}
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.deliverMessage(msg)
+			progress = true
+			i--
+		}
+	}
+}
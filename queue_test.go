@@ -0,0 +1,123 @@
+package vclock
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// broadcast ticks the clock of sender and returns a copy for the message.
+func broadcast(vc VClock, sender string) VClock {
+	vc.Tick(sender)
+	return vc.Copy()
+}
+
+func TestCausalQueueOrder(t *testing.T) {
+	var delivered []string
+	q := NewCausalQueue(nil, 0, func(m Message[string]) {
+		delivered = append(delivered, m.Payload)
+	})
+
+	a := New()
+	m1 := broadcast(a, "a")
+	m2 := broadcast(a, "a")
+
+	// b replies after delivering both messages of a
+	b := a.Copy()
+	m3 := broadcast(b, "b")
+
+	for _, err := range []error{
+		q.Push("b", m3, "b1"),
+		q.Push("a", m2, "a2"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(delivered) != 0 || q.Len() != 2 {
+		t.Fatalf("messages delivered before their predecessors: %v", delivered)
+	}
+
+	if err := q.Push("a", m1, "a1"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(delivered, ",") != "a1,a2,b1" || q.Len() != 0 {
+		t.Fatalf("messages not delivered in causal order: %v", delivered)
+	}
+	if q.Delivered().ReturnVCString() != "{\"a\":2, \"b\":1}" {
+		t.Fatalf("unexpected delivered clock %s", q.Delivered().ReturnVCString())
+	}
+
+	if err := q.Push("a", m1, "a1"); err != ErrDuplicateMessage {
+		t.Fatalf("expected ErrDuplicateMessage, got %v", err)
+	}
+}
+
+func TestCausalQueueLimits(t *testing.T) {
+	q := NewCausalQueue[int](nil, 1, nil)
+
+	a := New()
+	broadcast(a, "a")
+	m2 := broadcast(a, "a")
+	m3 := broadcast(a, "a")
+
+	if err := q.Push("a", m3, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Push("a", m3, 3); err != ErrDuplicateMessage {
+		t.Fatalf("expected ErrDuplicateMessage, got %v", err)
+	}
+	if err := q.Push("a", m2, 2); err != ErrQueueFull {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	waiting := q.Waiting()
+	if len(waiting) != 1 || waiting[0].Sender != "a" || waiting[0].Missing["a"] != (CounterRange{From: 1, To: 2}) {
+		t.Fatalf("unexpected waiting messages %+v", waiting)
+	}
+}
+
+func TestCausalQueueRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ids := []string{"a", "b", "c"}
+
+	// every process delivers its own broadcasts right away, and occasionally
+	// delivers everything sent so far before broadcasting
+	clocks := map[string]VClock{}
+	for _, id := range ids {
+		clocks[id] = New()
+	}
+	var sent []Message[int]
+	for i := 0; i < 100; i++ {
+		id := ids[r.Intn(len(ids))]
+		if r.Intn(3) == 0 {
+			for _, m := range sent {
+				clocks[id].Merge(m.Clock)
+			}
+		}
+		sent = append(sent, Message[int]{Sender: id, Clock: broadcast(clocks[id], id), Payload: i})
+	}
+
+	r.Shuffle(len(sent), func(i, j int) { sent[i], sent[j] = sent[j], sent[i] })
+
+	var delivered []Message[int]
+	q := NewCausalQueue(nil, 0, func(m Message[int]) {
+		delivered = append(delivered, m)
+	})
+	for _, m := range sent {
+		if err := q.Push(m.Sender, m.Clock, m.Payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(delivered) != len(sent) || q.Len() != 0 {
+		t.Fatalf("delivered %d of %d messages, %d stuck", len(delivered), len(sent), q.Len())
+	}
+	for i := range delivered {
+		for j := i + 1; j < len(delivered); j++ {
+			if delivered[j].Clock.Order(delivered[i].Clock) == Descendant {
+				t.Fatalf("message %d delivered before its predecessor %d", delivered[i].Payload, delivered[j].Payload)
+			}
+		}
+	}
+}
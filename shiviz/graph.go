@@ -0,0 +1,67 @@
+package shiviz
+
+import (
+	"sort"
+
+	"git.tu-berlin.de/mcc-fred/vclock"
+	"git.tu-berlin.de/mcc-fred/vclock/internal/hb"
+)
+
+// Edge is a happens-before edge of a Graph between the events with the
+// indices From and To.
+type Edge struct {
+	From int
+	To   int
+}
+
+// Graph is the happens-before graph of a set of events. It only holds the
+// edges between events and their direct predecessors, edges that follow from
+// other edges by transitivity are dropped.
+type Graph struct {
+	// Events are the events of the graph, in the order they were given.
+	Events []Event
+	// Edges are the edges of the graph, sorted by To and then by From.
+	Edges []Edge
+}
+
+// BuildGraph builds the happens-before graph of the given events using
+// vclock.VClock.Order. Events with equal clocks are not related.
+func BuildGraph(events []Event) *Graph {
+	clocks := make([]vclock.VClock, len(events))
+	for i, e := range events {
+		clocks[i] = e.Clock
+	}
+
+	g := &Graph{Events: events}
+	for to, preds := range hb.Reduce(clocks) {
+		for _, from := range preds {
+			g.Edges = append(g.Edges, Edge{From: from, To: to})
+		}
+	}
+	return g
+}
+
+// Predecessors returns the indices of the direct predecessors of the event
+// with index i in ascending order.
+func (g *Graph) Predecessors(i int) []int {
+	var preds []int
+	for _, e := range g.Edges {
+		if e.To == i {
+			preds = append(preds, e.From)
+		}
+	}
+	return preds
+}
+
+// Successors returns the indices of the direct successors of the event with
+// index i in ascending order.
+func (g *Graph) Successors(i int) []int {
+	var succs []int
+	for _, e := range g.Edges {
+		if e.From == i {
+			succs = append(succs, e.To)
+		}
+	}
+	sort.Ints(succs)
+	return succs
+}
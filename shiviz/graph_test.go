@@ -0,0 +1,33 @@
+package shiviz
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildGraph(t *testing.T) {
+	// a sends to b and c, both reply to a
+	log := strings.Join([]string{
+		"a {\"a\":1}", "send",
+		"b {\"a\":1, \"b\":1}", "receive",
+		"c {\"a\":1, \"c\":1}", "receive",
+		"b {\"a\":1, \"b\":2}", "reply",
+		"a {\"a\":2, \"b\":2}", "receive b",
+		"a {\"a\":3, \"b\":2, \"c\":1}", "receive c",
+	}, "\n")
+
+	events, err := Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := BuildGraph(events)
+	expected := []Edge{{0, 1}, {0, 2}, {1, 3}, {3, 4}, {2, 5}, {4, 5}}
+	if !reflect.DeepEqual(g.Edges, expected) {
+		t.Fatalf("unexpected edges %v", g.Edges)
+	}
+	if !reflect.DeepEqual(g.Predecessors(5), []int{2, 4}) || !reflect.DeepEqual(g.Successors(0), []int{1, 2}) {
+		t.Fatalf("unexpected neighbours %v %v", g.Predecessors(5), g.Successors(0))
+	}
+}
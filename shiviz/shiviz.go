@@ -0,0 +1,120 @@
+// Package shiviz parses logs in the format of ShiViz
+// (https://bestchai.bitbucket.io/shiviz/), such as the ones written by the
+// logger package, and builds the happens-before graph of the logged events.
+package shiviz
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+
+	"git.tu-berlin.de/mcc-fred/vclock"
+)
+
+// DefaultPattern is the regular expression that matches the logs written by
+// the logger package: the host and its vector clock on one line, followed by
+// a line with the event description.
+const DefaultPattern = `(?P<host>\S*) (?P<clock>{.*})\n(?P<event>.*)`
+
+// Event is a single event of a log.
+type Event struct {
+	// Host is the id of the process the event happened at.
+	Host string
+	// Clock is the vector clock of the event.
+	Clock vclock.VClock
+	// Message is the description of the event, the "event" group of the
+	// pattern.
+	Message string
+	// Fields holds the other named groups of the pattern.
+	Fields map[string]string
+	// Line is the line number the event starts at, starting at 1.
+	Line int
+}
+
+// Parser parses logs with a configurable regular expression. The expression
+// must contain the named groups "host" and "clock", where the clock is in the
+// format of vclock.Parse. An optional group "event" holds the event
+// description, all other named groups end up in Event.Fields.
+type Parser struct {
+	re *regexp.Regexp
+}
+
+// NewParser returns a parser for the given pattern, see Parser.
+func NewParser(pattern string) (*Parser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("shiviz: invalid pattern: %w", err)
+	}
+
+	for _, group := range []string{"host", "clock"} {
+		if re.SubexpIndex(group) < 0 {
+			return nil, fmt.Errorf("shiviz: pattern has no group %q", group)
+		}
+	}
+
+	return &Parser{re: re}, nil
+}
+
+// Parse parses a log with DefaultPattern.
+func Parse(r io.Reader) ([]Event, error) {
+	p, err := NewParser(DefaultPattern)
+	if err != nil {
+		return nil, err
+	}
+	return p.Parse(r)
+}
+
+// Parse reads the whole log from r and returns its events in the order in
+// which they appear. Text that does not match the pattern is skipped. Every
+// clock must contain an entry for the host of its event, as ShiViz requires.
+func (p *Parser) Parse(r io.Reader) ([]Event, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("shiviz: cannot read log: %w", err)
+	}
+
+	names := p.re.SubexpNames()
+	line, offset := 1, 0
+
+	var events []Event
+	for _, match := range p.re.FindAllSubmatchIndex(data, -1) {
+		line += bytes.Count(data[offset:match[0]], []byte{'\n'})
+		offset = match[0]
+
+		e := Event{Line: line}
+		var clock string
+		for i, name := range names {
+			if name == "" || match[2*i] < 0 {
+				continue
+			}
+			value := string(data[match[2*i]:match[2*i+1]])
+
+			switch name {
+			case "host":
+				e.Host = value
+			case "clock":
+				clock = value
+			case "event":
+				e.Message = value
+			default:
+				if e.Fields == nil {
+					e.Fields = map[string]string{}
+				}
+				e.Fields[name] = value
+			}
+		}
+
+		e.Clock, err = vclock.Parse(clock)
+		if err != nil {
+			return nil, fmt.Errorf("shiviz: invalid clock at line %d: %w", line, err)
+		}
+		if _, ok := e.Clock.FindTicks(e.Host); !ok {
+			return nil, fmt.Errorf("shiviz: clock at line %d has no entry for host %q", line, e.Host)
+		}
+
+		events = append(events, e)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,84 @@
+package shiviz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"git.tu-berlin.de/mcc-fred/vclock/logger"
+)
+
+func TestParseLoggerOutput(t *testing.T) {
+	var log bytes.Buffer
+	client, err := logger.New("client", &log, logger.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := logger.New("server", &log, logger.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := client.PrepareSend("Sending request", "ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload string
+	if err := server.UnpackReceive("Received request", buf, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := Parse(&log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	e := events[1]
+	if e.Host != "server" || e.Message != "Received request" || e.Line != 3 ||
+		e.Clock.ReturnVCString() != "{\"client\":1, \"server\":1}" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+}
+
+func TestParseCustomPattern(t *testing.T) {
+	log := "ignored preamble\n" +
+		"12:00:01 INFO a {\"a\":1} started\n" +
+		"12:00:02 WARN b {\"a\":1, \"b\":1} slow\n"
+
+	p, err := NewParser(`(?P<time>\S+) (?P<level>\S+) (?P<host>\S+) (?P<clock>{.*}) (?P<event>.*)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := p.Parse(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	e := events[1]
+	if e.Host != "b" || e.Message != "slow" || e.Line != 3 || e.Fields["level"] != "WARN" || e.Fields["time"] != "12:00:02" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, pattern := range []string{`(`, `(?P<host>\S*) (?P<event>.*)`} {
+		if _, err := NewParser(pattern); err == nil {
+			t.Fatalf("expected error for pattern %q", pattern)
+		}
+	}
+
+	for _, log := range []string{
+		"a {\"a\":x}\nevent\n",
+		"a {\"b\":1}\nevent\n",
+	} {
+		if _, err := Parse(strings.NewReader(log)); err == nil {
+			t.Fatalf("expected error for log %q", log)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+// Package simnet is a deterministic simulator of a message-passing system for
+// testing code that uses vector clocks.
+//
+// A Sim runs a set of virtual processes, each holding a vclock.VClock, in a
+// single goroutine. Messages are delayed by a random number of time steps,
+// which reorders them, may be dropped at random, and are lost if they cross a
+// partition. All randomness comes from a seeded source, so a simulation with
+// the same seed and the same calls always produces the same trace. The trace
+// records every event with its clock and the true happens-before relation,
+// against which tests can check the clocks.
+package simnet
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+
+	"git.tu-berlin.de/mcc-fred/vclock"
+)
+
+// Config configures a Sim.
+type Config struct {
+	// Seed seeds the random source of the simulation.
+	Seed int64
+	// MinDelay and MaxDelay bound the number of time steps a message is in
+	// flight. MaxDelay defaults to MinDelay+10 if it is less than MinDelay.
+	MinDelay int64
+	MaxDelay int64
+	// DropRate is the probability that a message is lost.
+	DropRate float64
+}
+
+// Process is a virtual process of a simulation.
+type Process struct {
+	// ID is the id of the process.
+	ID string
+	// Clock is the vector clock of the process. Handlers may change it.
+	Clock vclock.VClock
+
+	last int
+}
+
+// Message is a message sent between two processes.
+type Message struct {
+	// Seq numbers the messages of a simulation in the order they were sent.
+	Seq int
+	// From and To are the ids of the sender and the receiver.
+	From string
+	To   string
+	// Clock is the vector clock the sender attached to the message.
+	Clock vclock.VClock
+	// Payload is the payload of the message.
+	Payload interface{}
+
+	send    int
+	arrival int64
+	dropped bool
+}
+
+// Handler is called when process p receives message m. The default handler
+// merges the clock of the message into the clock of the process and ticks it.
+type Handler func(s *Sim, p *Process, m Message)
+
+// Kind is the kind of an event of a trace.
+type Kind int
+
+const (
+	// Local is a local event of a process.
+	Local Kind = iota
+	// Send is the send event of a message.
+	Send
+	// Receive is the receive event of a message.
+	Receive
+	// Drop records that a message was lost. It is not an event of any
+	// process and takes no part in the happens-before relation.
+	Drop
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Local:
+		return "local"
+	case Send:
+		return "send"
+	case Receive:
+		return "receive"
+	case Drop:
+		return "drop"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Event is an event of a trace.
+type Event struct {
+	Kind Kind
+	// Time is the simulated time of the event.
+	Time int64
+	// Process is the id of the process the event happened at. For Drop
+	// events, it is the id of the receiver.
+	Process string
+	// Clock is a copy of the clock of the process after the event. Drop
+	// events carry the clock of the message.
+	Clock vclock.VClock
+	// Label describes local events.
+	Label string
+	// Message is the sent, received, or dropped message, or nil for local
+	// events.
+	Message *Message
+
+	// prev and cause are the indices of the events that immediately happen
+	// before this one: the previous event of the process and, for receive
+	// events, the send event. -1 if there is no such event.
+	prev  int
+	cause int
+}
+
+// Sim is a simulation. It is not safe for concurrent use.
+type Sim struct {
+	cfg       Config
+	rand      *rand.Rand
+	now       int64
+	processes map[string]*Process
+	ids       []string
+	inFlight  messageQueue
+	seq       int
+	partition map[string]int
+	handler   Handler
+	trace     Trace
+}
+
+// New returns a new simulation with processes with the given ids.
+func New(cfg Config, ids ...string) *Sim {
+	if cfg.MaxDelay < cfg.MinDelay {
+		cfg.MaxDelay = cfg.MinDelay + 10
+	}
+
+	s := &Sim{
+		cfg:       cfg,
+		rand:      rand.New(rand.NewSource(cfg.Seed)),
+		processes: make(map[string]*Process, len(ids)),
+		handler:   defaultHandler,
+	}
+	for _, id := range ids {
+		if _, ok := s.processes[id]; ok {
+			panic(fmt.Sprintf("simnet: duplicate process id %q", id))
+		}
+		s.processes[id] = &Process{ID: id, Clock: vclock.New(), last: -1}
+		s.ids = append(s.ids, id)
+	}
+	return s
+}
+
+// defaultHandler merges the clock of the message and ticks the clock of the
+// receiver.
+func defaultHandler(_ *Sim, p *Process, m Message) {
+	p.Clock.Merge(m.Clock)
+	p.Clock.Tick(p.ID)
+}
+
+// HandleFunc replaces the handler that is called for received messages. A nil
+// handler restores the default.
+func (s *Sim) HandleFunc(h Handler) {
+	if h == nil {
+		h = defaultHandler
+	}
+	s.handler = h
+}
+
+// Rand returns the random source of the simulation, which tests can use to
+// generate reproducible workloads.
+func (s *Sim) Rand() *rand.Rand {
+	return s.rand
+}
+
+// Now returns the current simulated time.
+func (s *Sim) Now() int64 {
+	return s.now
+}
+
+// IDs returns the ids of the processes in the order given to New.
+func (s *Sim) IDs() []string {
+	return append([]string(nil), s.ids...)
+}
+
+// Process returns the process with the given id. It panics if there is no
+// such process.
+func (s *Sim) Process(id string) *Process {
+	p, ok := s.processes[id]
+	if !ok {
+		panic(fmt.Sprintf("simnet: unknown process id %q", id))
+	}
+	return p
+}
+
+// Trace returns the events of the simulation so far.
+func (s *Sim) Trace() Trace {
+	return s.trace
+}
+
+// Local records a local event at the process with the given id, which ticks
+// its clock.
+func (s *Sim) Local(id, label string) {
+	p := s.Process(id)
+	p.Clock.Tick(id)
+	s.record(p, Event{Kind: Local, Label: label, cause: -1})
+}
+
+// Send ticks the clock of the sender and sends a message with a copy of the
+// clock to the receiver. It returns the sequence number of the message.
+func (s *Sim) Send(from, to string, payload interface{}) int {
+	p := s.Process(from)
+	p.Clock.Tick(from)
+	return s.send(p, s.Process(to), p.Clock.Copy(), payload)
+}
+
+// Broadcast ticks the clock of the sender once and sends a message with a copy
+// of the clock to every other process, in the order given to New.
+func (s *Sim) Broadcast(from string, payload interface{}) {
+	p := s.Process(from)
+	p.Clock.Tick(from)
+	clock := p.Clock.Copy()
+	for _, id := range s.ids {
+		if id != from {
+			s.send(p, s.processes[id], clock, payload)
+		}
+	}
+}
+
+func (s *Sim) send(from, to *Process, clock vclock.VClock, payload interface{}) int {
+	delay := s.cfg.MinDelay
+	if s.cfg.MaxDelay > s.cfg.MinDelay {
+		delay += s.rand.Int63n(s.cfg.MaxDelay - s.cfg.MinDelay + 1)
+	}
+
+	m := &Message{
+		Seq:     s.seq,
+		From:    from.ID,
+		To:      to.ID,
+		Clock:   clock,
+		Payload: payload,
+		arrival: s.now + delay,
+		// a message sent across a partition is lost even if the
+		// partition heals before it arrives
+		dropped: s.rand.Float64() < s.cfg.DropRate || !s.connected(from.ID, to.ID),
+	}
+	s.seq++
+
+	m.send = s.record(from, Event{Kind: Send, Message: m, cause: -1})
+	heap.Push(&s.inFlight, m)
+	return m.Seq
+}
+
+// Partition splits the processes into the given groups. Messages between
+// processes in different groups are lost: the ones sent while the partition
+// lasts, even if it heals before they arrive, and the ones already in flight
+// that arrive before it heals. Processes that are not part of any group form a
+// group of their own.
+func (s *Sim) Partition(groups ...[]string) {
+	s.partition = map[string]int{}
+	for i, group := range groups {
+		for _, id := range group {
+			s.Process(id)
+			s.partition[id] = i + 1
+		}
+	}
+}
+
+// Heal removes the partition.
+func (s *Sim) Heal() {
+	s.partition = nil
+}
+
+// connected reports whether messages can pass between the given processes.
+func (s *Sim) connected(a, b string) bool {
+	return s.partition == nil || s.partition[a] == s.partition[b]
+}
+
+// InFlight returns the number of messages that are in flight.
+func (s *Sim) InFlight() int {
+	return len(s.inFlight)
+}
+
+// Step advances the simulated time to the arrival of the next message and
+// delivers it, or records that it was lost. Messages with the same arrival
+// time arrive in the order they were sent. Step returns false if no message
+// is in flight.
+func (s *Sim) Step() bool {
+	if len(s.inFlight) == 0 {
+		return false
+	}
+
+	m := heap.Pop(&s.inFlight).(*Message)
+	if m.arrival > s.now {
+		s.now = m.arrival
+	}
+
+	if m.dropped || !s.connected(m.From, m.To) {
+		s.trace = append(s.trace, Event{
+			Kind:    Drop,
+			Time:    s.now,
+			Process: m.To,
+			Clock:   m.Clock,
+			Message: m,
+			prev:    -1,
+			cause:   -1,
+		})
+		return true
+	}
+
+	p := s.processes[m.To]
+	s.handler(s, p, *m)
+	s.record(p, Event{Kind: Receive, Message: m, cause: m.send})
+	return true
+}
+
+// Run steps until no message is in flight.
+func (s *Sim) Run() {
+	for s.Step() {
+	}
+}
+
+// record appends an event of process p to the trace and returns its index.
+func (s *Sim) record(p *Process, e Event) int {
+	e.Time = s.now
+	e.Process = p.ID
+	e.Clock = p.Clock.Copy()
+	e.prev = p.last
+
+	p.last = len(s.trace)
+	s.trace = append(s.trace, e)
+	return p.last
+}
+
+// messageQueue orders messages by arrival time and sequence number.
+type messageQueue []*Message
+
+func (q messageQueue) Len() int { return len(q) }
+
+func (q messageQueue) Less(i, j int) bool {
+	if q[i].arrival != q[j].arrival {
+		return q[i].arrival < q[j].arrival
+	}
+	return q[i].Seq < q[j].Seq
+}
+
+func (q messageQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *messageQueue) Push(x interface{}) { *q = append(*q, x.(*Message)) }
+
+func (q *messageQueue) Pop() interface{} {
+	old := *q
+	m := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return m
+}
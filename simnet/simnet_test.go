@@ -0,0 +1,201 @@
+package simnet
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"git.tu-berlin.de/mcc-fred/vclock"
+)
+
+// randomWorkload runs a random mix of local events, messages, partitions, and
+// steps.
+func randomWorkload(s *Sim, steps int) {
+	r := s.Rand()
+	ids := s.IDs()
+
+	for i := 0; i < steps; i++ {
+		switch n := r.Intn(20); {
+		case n < 6:
+			s.Send(ids[r.Intn(len(ids))], ids[r.Intn(len(ids))], i)
+		case n < 8:
+			s.Local(ids[r.Intn(len(ids))], fmt.Sprint(i))
+		case n == 8:
+			s.Partition(ids[:r.Intn(len(ids))])
+		case n == 9:
+			s.Heal()
+		default:
+			s.Step()
+		}
+	}
+	s.Heal()
+	s.Run()
+}
+
+func TestOrderAgreesWithHappensBefore(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		s := New(Config{Seed: seed, MaxDelay: 5, DropRate: 0.1}, "a", "b", "c", "d")
+		randomWorkload(s, 200)
+
+		trace := s.Trace()
+		for i := range trace {
+			for j := range trace {
+				if trace[i].Kind == Drop || trace[j].Kind == Drop || i == j {
+					continue
+				}
+
+				var expected vclock.Condition
+				switch {
+				case trace.HappensBefore(i, j):
+					expected = vclock.Descendant
+				case trace.HappensBefore(j, i):
+					expected = vclock.Ancestor
+				default:
+					expected = vclock.Concurrent
+				}
+
+				if order := trace[i].Clock.Order(trace[j].Clock); order != expected {
+					t.Fatalf("seed %d: order of events %d %s and %d %s is %s, expected %s", seed,
+						i, trace[i].Clock.ReturnVCString(), j, trace[j].Clock.ReturnVCString(), order, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	run := func() Trace {
+		s := New(Config{Seed: 42, MinDelay: 1, MaxDelay: 10, DropRate: 0.2}, "a", "b", "c")
+		randomWorkload(s, 100)
+		return s.Trace()
+	}
+
+	if !reflect.DeepEqual(run(), run()) {
+		t.Fatalf("simulations with the same seed differ")
+	}
+}
+
+func TestPartition(t *testing.T) {
+	s := New(Config{MinDelay: 1, MaxDelay: 1}, "a", "b", "c")
+
+	s.Partition([]string{"a", "b"})
+	s.Send("a", "b", "same side")
+	s.Send("a", "c", "other side")
+	s.Run()
+
+	s.Heal()
+	s.Send("a", "c", "healed")
+	s.Run()
+
+	var kinds []string
+	for _, e := range s.Trace() {
+		kinds = append(kinds, fmt.Sprintf("%s@%s", e.Kind, e.Process))
+	}
+	expected := "[send@a send@a receive@b drop@c send@a receive@c]"
+	if fmt.Sprint(kinds) != expected {
+		t.Fatalf("unexpected trace %v", kinds)
+	}
+
+	if c := s.Process("c").Clock.ReturnVCString(); c != "{\"a\":3, \"c\":1}" {
+		t.Fatalf("unexpected clock %s", c)
+	}
+}
+
+func TestPartitionHealsInFlight(t *testing.T) {
+	s := New(Config{MinDelay: 5, MaxDelay: 5}, "a", "b")
+
+	s.Partition([]string{"a"}, []string{"b"})
+	s.Send("a", "b", "sent across the partition")
+	s.Heal()
+	s.Send("a", "b", "sent after healing")
+	s.Run()
+
+	var kinds []string
+	for _, e := range s.Trace() {
+		kinds = append(kinds, fmt.Sprintf("%s@%s", e.Kind, e.Process))
+	}
+	expected := "[send@a send@a drop@b receive@b]"
+	if fmt.Sprint(kinds) != expected {
+		t.Fatalf("unexpected trace %v", kinds)
+	}
+
+	// a message sent before the partition and arriving during it is lost
+	s.Send("a", "b", "in flight")
+	s.Partition([]string{"a"}, []string{"b"})
+	s.Run()
+
+	if e := s.Trace()[len(s.Trace())-1]; e.Kind != Drop {
+		t.Fatalf("expected in-flight message to be dropped, got %s@%s", e.Kind, e.Process)
+	}
+}
+
+// TestCausalBroadcast runs causal broadcast on top of vclock.CausalQueue and
+// checks that no delivery violates Deliverable, although the network reorders
+// messages.
+func TestCausalBroadcast(t *testing.T) {
+	ids := []string{"a", "b", "c", "d"}
+	s := New(Config{Seed: 1, MaxDelay: 20}, ids...)
+
+	queues := map[string]*vclock.CausalQueue[int]{}
+	early := 0
+	delivered := 0
+	for _, id := range ids {
+		p := s.Process(id)
+		queues[id] = vclock.NewCausalQueue(nil, 0, func(m vclock.Message[int]) {
+			// a process delivers its own broadcasts right away
+			if m.Sender == p.ID {
+				return
+			}
+			if !p.Clock.Deliverable(m.Sender, m.Clock) {
+				t.Fatalf("delivery of %s at %s violates Deliverable: %s", m.Clock.ReturnVCString(), p.ID, p.Clock.ReturnVCString())
+			}
+			p.Clock.Merge(m.Clock)
+			delivered++
+		})
+	}
+
+	s.HandleFunc(func(_ *Sim, p *Process, m Message) {
+		if !p.Clock.Deliverable(m.From, m.Clock) {
+			early++
+		}
+		if err := queues[p.ID].Push(m.From, m.Clock, m.Payload.(int)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	r := s.Rand()
+	for i := 0; i < 100; i++ {
+		if r.Intn(2) == 0 {
+			id := ids[r.Intn(len(ids))]
+			s.Broadcast(id, i)
+			if err := queues[id].Push(id, s.Process(id).Clock, i); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			s.Step()
+		}
+	}
+	s.Run()
+
+	if early == 0 {
+		t.Fatalf("network did not reorder any messages")
+	}
+	if delivered != s.Trace().count(Receive) {
+		t.Fatalf("delivered %d of %d messages", delivered, s.Trace().count(Receive))
+	}
+	for _, id := range ids[1:] {
+		if s.Process(id).Clock.Order(s.Process(ids[0]).Clock) != vclock.Equal {
+			t.Fatalf("processes did not converge")
+		}
+	}
+}
+
+func (t Trace) count(kind Kind) int {
+	n := 0
+	for _, e := range t {
+		if e.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
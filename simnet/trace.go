@@ -0,0 +1,34 @@
+package simnet
+
+// Trace is the sequence of events of a simulation, in the order in which they
+// happened.
+type Trace []Event
+
+// HappensBefore reports whether event i happens before event j according to
+// the true causality of the simulation: i is an earlier event of the same
+// process, or there is a chain of such events and messages from i to j. Drop
+// events never happen before or after other events.
+func (t Trace) HappensBefore(i, j int) bool {
+	if t[i].Kind == Drop || t[j].Kind == Drop || i >= j {
+		return false
+	}
+
+	visited := make([]bool, j+1)
+	stack := []int{j}
+	for len(stack) > 0 {
+		k := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, pred := range []int{t[k].prev, t[k].cause} {
+			if pred == i {
+				return true
+			}
+			// events before i cannot lead to it
+			if pred > i && !visited[pred] {
+				visited[pred] = true
+				stack = append(stack, pred)
+			}
+		}
+	}
+	return false
+}
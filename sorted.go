@@ -0,0 +1,205 @@
+package vclock
+
+import "sort"
+
+// Entry is the clock value of a single process.
+type Entry struct {
+	ID    string
+	Ticks uint64
+}
+
+// SortedClock is a vector clock that stores its entries in a slice sorted by
+// process id. Unlike with a VClock, iterating over the entries always yields
+// the same order, and Merge, Order, and Diff walk both clocks side by side in
+// linear time instead of looking up every id in a map.
+//
+// A SortedClock must be sorted by id and must not contain an id twice, which
+// all clocks returned by this package are. Just like VClock, a SortedClock is
+// not safe for concurrent use.
+type SortedClock []Entry
+
+// NewSortedClock returns the given vector clock as a SortedClock.
+func NewSortedClock(vc VClock) SortedClock {
+	c := make(SortedClock, 0, len(vc))
+	for id, ticks := range vc {
+		c = append(c, Entry{ID: id, Ticks: ticks})
+	}
+	sort.Slice(c, func(i, j int) bool { return c[i].ID < c[j].ID })
+	return c
+}
+
+// VClock returns the clock as a new VClock.
+func (c SortedClock) VClock() VClock {
+	vc := make(VClock, len(c))
+	for _, e := range c {
+		vc[e.ID] = e.Ticks
+	}
+	return vc
+}
+
+// Copy returns a copy of the clock.
+func (c SortedClock) Copy() SortedClock {
+	return append(SortedClock(nil), c...)
+}
+
+// search returns the position of the given id in the clock, or the position
+// at which it would be inserted, and whether the id is part of the clock.
+func (c SortedClock) search(id string) (int, bool) {
+	i := sort.Search(len(c), func(i int) bool { return c[i].ID >= id })
+	return i, i < len(c) && c[i].ID == id
+}
+
+// FindTicks returns the clock value for a given id or false if the id is not
+// found.
+func (c SortedClock) FindTicks(id string) (uint64, bool) {
+	if i, ok := c.search(id); ok {
+		return c[i].Ticks, true
+	}
+	return 0, false
+}
+
+// Set sets the clock value of the given process id to the given value.
+func (c *SortedClock) Set(id string, ticks uint64) {
+	i, ok := c.search(id)
+	if !ok {
+		*c = append(*c, Entry{})
+		copy((*c)[i+1:], (*c)[i:])
+		(*c)[i].ID = id
+	}
+	(*c)[i].Ticks = ticks
+}
+
+// Tick increments the clock value of the given process id by 1. If the
+// process id is not found in the clock, it is added with a value of 1.
+func (c *SortedClock) Tick(id string) {
+	ticks, _ := c.FindTicks(id)
+	c.Set(id, ticks+1)
+}
+
+// Merge takes the maximum of all clock values in other and updates the values
+// of the callee, see VClock.Merge. If other has no ids that the callee does not
+// have, Merge updates the callee in place without allocating.
+func (c *SortedClock) Merge(other SortedClock) {
+	if c.mergeInPlace(other) {
+		return
+	}
+
+	merged := make(SortedClock, 0, len(*c)+len(other))
+
+	i, j := 0, 0
+	for i < len(*c) && j < len(other) {
+		a, b := (*c)[i], other[j]
+		switch {
+		case a.ID < b.ID:
+			merged = append(merged, a)
+			i++
+		case a.ID > b.ID:
+			merged = append(merged, b)
+			j++
+		default:
+			if b.Ticks > a.Ticks {
+				a.Ticks = b.Ticks
+			}
+			merged = append(merged, a)
+			i++
+			j++
+		}
+	}
+	merged = append(merged, (*c)[i:]...)
+	merged = append(merged, other[j:]...)
+
+	*c = merged
+}
+
+// mergeInPlace merges other into the callee if all ids of other are part of
+// the callee. Otherwise, it returns false and leaves the callee unchanged.
+func (c SortedClock) mergeInPlace(other SortedClock) bool {
+	i := 0
+	for _, b := range other {
+		for i < len(c) && c[i].ID < b.ID {
+			i++
+		}
+		if i == len(c) || c[i].ID != b.ID {
+			return false
+		}
+	}
+
+	i = 0
+	for _, b := range other {
+		for c[i].ID < b.ID {
+			i++
+		}
+		if b.Ticks > c[i].Ticks {
+			c[i].Ticks = b.Ticks
+		}
+	}
+	return true
+}
+
+// Order determines the relationship between two clocks, see VClock.Order.
+func (c SortedClock) Order(other SortedClock) Condition {
+	cBigger := false
+	otherBigger := false
+
+	i, j := 0, 0
+	for (i < len(c) || j < len(other)) && !(cBigger && otherBigger) {
+		switch {
+		case j == len(other) || (i < len(c) && c[i].ID < other[j].ID):
+			cBigger = true
+			i++
+		case i == len(c) || other[j].ID < c[i].ID:
+			otherBigger = true
+			j++
+		default:
+			if c[i].Ticks > other[j].Ticks {
+				cBigger = true
+			} else if c[i].Ticks < other[j].Ticks {
+				otherBigger = true
+			}
+			i++
+			j++
+		}
+	}
+
+	return condition(cBigger, otherBigger)
+}
+
+// Compare takes another clock and determines if it is Equal, an Ancestor,
+// Descendant, or Concurrent with the callee according to Order. The condition
+// may be ORed, see VClock.Compare.
+func (c SortedClock) Compare(other SortedClock, cond Condition) bool {
+	return c.Order(other)&cond != 0
+}
+
+// Diff returns the entries of the clock that other does not know about: the
+// entries whose process id is missing from other or whose clock value is
+// greater than the one in other. Merging the result into other has the same
+// effect as merging the whole clock.
+func (c SortedClock) Diff(other SortedClock) SortedClock {
+	var diff SortedClock
+
+	j := 0
+	for _, e := range c {
+		for j < len(other) && other[j].ID < e.ID {
+			j++
+		}
+		if j == len(other) || other[j].ID != e.ID || e.Ticks > other[j].Ticks {
+			diff = append(diff, e)
+		}
+	}
+	return diff
+}
+
+// String returns the clock in the format of VClock.ReturnVCString.
+func (c SortedClock) String() string {
+	return string(c.appendText(nil))
+}
+
+// appendText appends the text representation of the clock to dst.
+func (c SortedClock) appendText(dst []byte) []byte {
+	dst = append(dst, '{')
+	for i, e := range c {
+		dst = appendEntry(dst, i == 0, e.ID, e.Ticks)
+	}
+	return append(dst, '}')
+}
@@ -0,0 +1,109 @@
+package vclock
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestSortedClockAgreesWithVClock(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		a, b := randomVClock(r, 8), randomVClock(r, 8)
+		sa, sb := NewSortedClock(a), NewSortedClock(b)
+
+		if sa.String() != a.ReturnVCString() || !reflect.DeepEqual(sa.VClock(), a) {
+			t.Fatalf("conversion of %s gave %s", a.ReturnVCString(), sa)
+		}
+		if sa.Order(sb) != a.Order(b) || !sa.Compare(sb, a.Order(b)) {
+			failComparison(t, "Order does not agree with VClock: a = %s | b = %s", a, b)
+		}
+
+		// merging the diff has the same effect as merging the whole clock
+		diff := sa.Diff(sb)
+		fromDiff := sb.Copy()
+		fromDiff.Merge(diff)
+		full := sb.Copy()
+		full.Merge(sa)
+		if !reflect.DeepEqual(fromDiff, full) {
+			t.Fatalf("merging diff %s of %s into %s gave %s, expected %s", diff, sa, sb, fromDiff, full)
+		}
+		for _, e := range diff {
+			if ticks, ok := sb.FindTicks(e.ID); ok && ticks >= e.Ticks {
+				t.Fatalf("diff %s of %s and %s contains a known entry", diff, sa, sb)
+			}
+		}
+
+		id := strconv.Itoa(r.Intn(10))
+		sa.Tick(id)
+		a.Tick(id)
+		sa.Merge(sb)
+		a.Merge(b)
+		if sa.String() != a.ReturnVCString() {
+			t.Fatalf("Tick and Merge gave %s, expected %s", sa, a.ReturnVCString())
+		}
+	}
+}
+
+func TestSortedClockSet(t *testing.T) {
+	var c SortedClock
+	for _, id := range []string{"c", "a", "d", "b", "a"} {
+		c.Tick(id)
+	}
+	c.Set("e", 0)
+
+	expected := SortedClock{{"a", 2}, {"b", 1}, {"c", 1}, {"d", 1}, {"e", 0}}
+	if !reflect.DeepEqual(c, expected) {
+		t.Fatalf("unexpected clock %s", c)
+	}
+	if _, ok := c.FindTicks("f"); ok {
+		t.Fatalf("found unknown id")
+	}
+}
+
+func BenchmarkSortedClockOrder(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			c1 := NewSortedClock(genVClock(size))
+			c2 := NewSortedClock(genVClock(size))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c1.Order(c2)
+			}
+		})
+	}
+}
+
+func BenchmarkSortedClockMerge(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			c1 := NewSortedClock(genVClock(size))
+			c2 := NewSortedClock(genVClock(size))
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c1.Merge(c2)
+			}
+		})
+	}
+}
+
+func BenchmarkMerge(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			vc1 := genVClock(size)
+			vc2 := genVClock(size)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				vc1.Merge(vc2)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package vclock
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+)
+
+// SQLFormat selects the representation that Value uses to store vector
+// clocks in a database.
+type SQLFormat int
+
+const (
+	// SQLBinary stores clocks in the compact binary encoding of Bytes, which
+	// suits bytea or BLOB columns.
+	SQLBinary SQLFormat = iota
+	// SQLText stores clocks in the text encoding of ReturnVCString, which is
+	// also valid JSON and suits text or JSON columns.
+	SQLText
+)
+
+// DefaultSQLFormat is the representation that Value writes. It applies to all
+// clocks in the program, so it should be set once during initialization.
+// Scan accepts all representations, regardless of this setting.
+var DefaultSQLFormat = SQLBinary
+
+// Value implements the driver.Valuer interface. It encodes the vector clock
+// according to DefaultSQLFormat. A nil clock is stored as NULL.
+func (vc VClock) Value() (driver.Value, error) {
+	if vc == nil {
+		return nil, nil
+	}
+
+	switch DefaultSQLFormat {
+	case SQLBinary:
+		return vc.MarshalBinary()
+	case SQLText:
+		return vc.ReturnVCString(), nil
+	default:
+		return nil, fmt.Errorf("vclock: unknown SQL format %d", DefaultSQLFormat)
+	}
+}
+
+// Scan implements the sql.Scanner interface. It accepts the compact binary
+// encoding, the gob encoding of earlier versions, and the text and JSON
+// encodings, either as a string or as a byte slice. NULL is scanned as a nil
+// clock.
+func (vc *VClock) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*vc = nil
+		return nil
+	case string:
+		return vc.UnmarshalText([]byte(src))
+	case []byte:
+		if isCompactBinary(src) {
+			return vc.UnmarshalBinary(src)
+		}
+		if trimmed := bytes.TrimLeft(src, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+			return vc.UnmarshalText(src)
+		}
+		clock, err := FromBytes(src)
+		if err != nil {
+			return err
+		}
+		*vc = clock
+		return nil
+	default:
+		return fmt.Errorf("vclock: cannot scan %T into a vector clock", src)
+	}
+}
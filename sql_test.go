@@ -0,0 +1,253 @@
+package vclock
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+var (
+	_ sql.Scanner   = &VClock{}
+	_ driver.Valuer = VClock{}
+)
+
+// fakeDriver is a minimal database/sql driver that stores the values of a
+// single column per data source name in memory. It understands two queries:
+// "INSERT" appends its only argument to the column, "SELECT" returns all
+// values of the column.
+type fakeDriver struct {
+	mu     sync.Mutex
+	tables map[string][]driver.Value
+}
+
+func init() {
+	sql.Register("vclock-fake", &fakeDriver{tables: map[string][]driver.Value{}})
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d: d, name: name}, nil
+}
+
+type fakeConn struct {
+	d    *fakeDriver
+	name string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if query != "INSERT" && query != "SELECT" {
+		return nil, errors.New("fake: unknown query " + query)
+	}
+	return &fakeStmt{c: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake: transactions are not supported")
+}
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	if s.query == "INSERT" {
+		return 1
+	}
+	return 0
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+
+	v := args[0]
+	if b, ok := v.([]byte); ok {
+		v = append([]byte(nil), b...)
+	}
+	s.c.d.tables[s.c.name] = append(s.c.d.tables[s.c.name], v)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+
+	values := append([]driver.Value(nil), s.c.d.tables[s.c.name]...)
+	return &fakeRows{values: values}, nil
+}
+
+type fakeRows struct {
+	values []driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"clock"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.values[0]
+	r.values = r.values[1:]
+	return nil
+}
+
+// roundTripSQL stores the given clocks in a fresh fake database and reads them
+// back.
+func roundTripSQL(t *testing.T, name string, clocks ...VClock) []VClock {
+	db, err := sql.Open("vclock-fake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, c := range clocks {
+		if _, err := db.Exec("INSERT", c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var decoded []VClock
+	for rows.Next() {
+		var c VClock
+		if err := rows.Scan(&c); err != nil {
+			t.Fatal(err)
+		}
+		decoded = append(decoded, c)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestSQLRoundTrip(t *testing.T) {
+	defer func(f SQLFormat) { DefaultSQLFormat = f }(DefaultSQLFormat)
+
+	for _, format := range []SQLFormat{SQLBinary, SQLText} {
+		DefaultSQLFormat = format
+
+		n := genVClock(10)
+		n.Set("quote\"", 1)
+
+		decoded := roundTripSQL(t, fmt.Sprintf("%s-%d", t.Name(), format), n, New(), nil)
+		if len(decoded) != 3 {
+			t.Fatalf("expected 3 clocks, got %d", len(decoded))
+		}
+
+		if !n.Compare(decoded[0], Equal) {
+			failComparison(t, "decoded not the same as encoded enc = %s | dec = %s", n, decoded[0])
+		}
+		if decoded[1] == nil || len(decoded[1]) != 0 {
+			t.Fatalf("expected empty clock, got %s", decoded[1].ReturnVCString())
+		}
+		if decoded[2] != nil {
+			t.Fatalf("expected nil clock for NULL, got %s", decoded[2].ReturnVCString())
+		}
+	}
+}
+
+func TestValueFormat(t *testing.T) {
+	defer func(f SQLFormat) { DefaultSQLFormat = f }(DefaultSQLFormat)
+
+	n := genVClock(3)
+
+	DefaultSQLFormat = SQLBinary
+	if v, err := n.Value(); err != nil || !bytes.Equal(v.([]byte), n.Bytes()) {
+		t.Fatalf("expected binary value %x, got %v (%v)", n.Bytes(), v, err)
+	}
+
+	DefaultSQLFormat = SQLText
+	if v, err := n.Value(); err != nil || v.(string) != n.ReturnVCString() {
+		t.Fatalf("expected text value %s, got %v (%v)", n.ReturnVCString(), v, err)
+	}
+
+	DefaultSQLFormat = SQLFormat(42)
+	if _, err := n.Value(); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestScanRepresentations(t *testing.T) {
+	n := genVClock(10)
+
+	j, err := n.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := map[string]interface{}{
+		"binary":      n.Bytes(),
+		"text string": n.ReturnVCString(),
+		"text bytes":  []byte(n.ReturnVCString()),
+		"json bytes":  append([]byte("\n  "), j...),
+	}
+
+	for name, src := range sources {
+		var decoded VClock
+		if err := decoded.Scan(src); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !n.Compare(decoded, Equal) {
+			failComparison(t, name+": decoded not the same as encoded enc = %s | dec = %s", n, decoded)
+		}
+	}
+}
+
+func TestScanLegacyGob(t *testing.T) {
+	data, err := hex.DecodeString(legacyGob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded VClock
+	if err := decoded.Scan(data); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := VClock{"a": 1, "b": 2}
+	if !expected.Compare(decoded, Equal) {
+		failComparison(t, "decoded not the same as encoded enc = %s | dec = %s", expected, decoded)
+	}
+}
+
+func TestScanInvalid(t *testing.T) {
+	sources := map[string]interface{}{
+		"int":       int64(1),
+		"text":      "{\"a\":-1}",
+		"binary":    []byte{binaryMagic, binaryVersion},
+		"gibberish": []byte("gibberish"),
+	}
+
+	for name, src := range sources {
+		var decoded VClock
+		if err := decoded.Scan(src); err == nil {
+			t.Fatalf("%s: expected error, got %s", name, decoded.ReturnVCString())
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package vclock
+
+import "sync"
+
+// SyncClock is a vector clock that is safe for concurrent use by multiple
+// goroutines. It wraps a VClock behind a sync.RWMutex. The compound operations
+// TickAndSnapshot and MergeAndTick make a send or receive event a single,
+// indivisible step.
+//
+// The zero value is an empty clock ready to use. A SyncClock must not be
+// copied after first use.
+type SyncClock struct {
+	mu sync.RWMutex
+	vc VClock
+}
+
+// NewSyncClock returns a new SyncClock that starts out with a copy of the
+// given vector clock. vc may be nil to start with an empty clock.
+func NewSyncClock(vc VClock) *SyncClock {
+	return &SyncClock{vc: vc.Copy()}
+}
+
+// Tick increments the clock value of the given process id by 1.
+func (sc *SyncClock) Tick(id string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.lazyInit()
+	sc.vc.Tick(id)
+}
+
+// Set sets the clock value of the given process id to the given value.
+func (sc *SyncClock) Set(id string, ticks uint64) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.lazyInit()
+	sc.vc.Set(id, ticks)
+}
+
+// Merge takes the maximum of all clock values in other and updates the values
+// of the clock, see VClock.Merge.
+func (sc *SyncClock) Merge(other VClock) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.lazyInit()
+	sc.vc.Merge(other)
+}
+
+// FindTicks returns the clock value for a given id or false if the id is not
+// found.
+func (sc *SyncClock) FindTicks(id string) (uint64, bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	return sc.vc.FindTicks(id)
+}
+
+// Order determines the relationship between the clock and other, see
+// VClock.Order.
+func (sc *SyncClock) Order(other VClock) Condition {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	return sc.vc.Order(other)
+}
+
+// Snapshot returns a deep copy of the current state of the clock. The copy is
+// not affected by later changes to the clock.
+func (sc *SyncClock) Snapshot() VClock {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	return sc.vc.Copy()
+}
+
+// TickAndSnapshot increments the clock value of the given process id and
+// returns a copy of the resulting clock in one atomic step. Use it for send
+// events, where the returned clock is attached to the outgoing message.
+func (sc *SyncClock) TickAndSnapshot(id string) VClock {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.lazyInit()
+	sc.vc.Tick(id)
+	return sc.vc.Copy()
+}
+
+// MergeAndTick merges other into the clock, increments the clock value of the
+// given process id, and returns a copy of the resulting clock in one atomic
+// step. Use it for receive events, where other is the clock attached to the
+// incoming message.
+func (sc *SyncClock) MergeAndTick(other VClock, id string) VClock {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.lazyInit()
+	sc.vc.Merge(other)
+	sc.vc.Tick(id)
+	return sc.vc.Copy()
+}
+
+// lazyInit makes the zero value usable, sc.mu must be held for writing.
+func (sc *SyncClock) lazyInit() {
+	if sc.vc == nil {
+		sc.vc = New()
+	}
+}
@@ -0,0 +1,138 @@
+package vclock
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSyncClockZeroValue(t *testing.T) {
+	var sc SyncClock
+
+	if _, ok := sc.FindTicks("a"); ok {
+		t.Fatalf("zero clock is not empty: %s", sc.Snapshot().ReturnVCString())
+	}
+
+	sc.Tick("a")
+	sc.Set("b", 5)
+
+	if ticks, _ := sc.FindTicks("a"); ticks != 1 {
+		t.Fatalf("Tick value did not increment: %s", sc.Snapshot().ReturnVCString())
+	}
+	if ticks, _ := sc.FindTicks("b"); ticks != 5 {
+		t.Fatalf("Set value not as expected: %s", sc.Snapshot().ReturnVCString())
+	}
+}
+
+func TestSyncClockSnapshotIsCopy(t *testing.T) {
+	n := New()
+	n.Set("a", 1)
+
+	sc := NewSyncClock(n)
+	n.Tick("a")
+
+	snapshot := sc.Snapshot()
+	sc.Tick("a")
+	snapshot.Tick("b")
+
+	if ticks, _ := sc.FindTicks("a"); ticks != 2 {
+		t.Fatalf("clock not independent of its input: %s", sc.Snapshot().ReturnVCString())
+	}
+	if _, ok := sc.FindTicks("b"); ok {
+		t.Fatalf("clock not independent of its snapshot: %s", sc.Snapshot().ReturnVCString())
+	}
+	if sc.Order(snapshot) != Concurrent {
+		failComparison(t, "Clocks not defined as Concurrent: n1 = %s | n2 = %s", sc.Snapshot(), snapshot)
+	}
+}
+
+func TestSyncClockConcurrentTick(t *testing.T) {
+	const goroutines = 16
+	const ticks = 1000
+
+	var sc SyncClock
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			for i := 0; i < ticks; i++ {
+				sc.Tick("shared")
+				sc.Tick(id)
+				sc.Snapshot()
+			}
+		}(strconv.Itoa(g))
+	}
+	wg.Wait()
+
+	if n, _ := sc.FindTicks("shared"); n != goroutines*ticks {
+		t.Fatalf("expected %d ticks, got %d", goroutines*ticks, n)
+	}
+	for g := 0; g < goroutines; g++ {
+		if n, _ := sc.FindTicks(strconv.Itoa(g)); n != ticks {
+			t.Fatalf("expected %d ticks for %d, got %d", ticks, g, n)
+		}
+	}
+}
+
+func TestSyncClockTickAndSnapshotAtomic(t *testing.T) {
+	const goroutines = 8
+	const ticks = 500
+
+	var sc SyncClock
+	var wg sync.WaitGroup
+	seen := make([][]uint64, goroutines)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < ticks; i++ {
+				seen[g] = append(seen[g], sc.TickAndSnapshot("a")["a"])
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// every snapshot must contain exactly its own tick, so all values are unique
+	unique := make(map[uint64]struct{})
+	for _, values := range seen {
+		for _, v := range values {
+			if _, ok := unique[v]; ok {
+				t.Fatalf("value %d was returned by more than one TickAndSnapshot", v)
+			}
+			unique[v] = struct{}{}
+		}
+	}
+	if len(unique) != goroutines*ticks {
+		t.Fatalf("expected %d unique values, got %d", goroutines*ticks, len(unique))
+	}
+}
+
+func TestSyncClockMergeAndTick(t *testing.T) {
+	const goroutines = 8
+
+	var sc SyncClock
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			msg := New()
+			msg.Set("sender"+strconv.Itoa(g), uint64(g+1))
+			got := sc.MergeAndTick(msg, "self")
+			if got.Order(msg) != Ancestor {
+				t.Errorf("Clocks not defined as Ancestor: n1 = %s | n2 = %s", got.ReturnVCString(), msg.ReturnVCString())
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if n, _ := sc.FindTicks("self"); n != goroutines {
+		t.Fatalf("expected %d ticks, got %d", goroutines, n)
+	}
+	for g := 0; g < goroutines; g++ {
+		if n, _ := sc.FindTicks("sender" + strconv.Itoa(g)); n != uint64(g+1) {
+			t.Fatalf("expected %d for sender %d, got %d", g+1, g, n)
+		}
+	}
+}
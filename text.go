@@ -0,0 +1,339 @@
+package vclock
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// SyntaxError describes a problem in the text representation of a vector clock
+// and where it was found.
+type SyntaxError struct {
+	// Offset is the byte offset in the input at which the problem was found.
+	Offset int
+	// Msg describes the problem.
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("vclock: %s at offset %d", e.Msg, e.Offset)
+}
+
+// Parse parses the text representation of a vector clock as returned by
+// ReturnVCString. Process ids are double-quoted, valid UTF-8 strings that may
+// contain the escape sequences of JSON strings, counters are non-negative
+// decimal integers. Since the format is a subset of JSON, Parse also accepts
+// the output of MarshalJSON. If the input is malformed, the returned error is a
+// *SyntaxError that points to the byte offset of the problem.
+func Parse(s string) (VClock, error) {
+	p := parser{s: s}
+	return p.parse()
+}
+
+// MarshalText implements the encoding.TextMarshaler interface using the format
+// of ReturnVCString.
+func (vc VClock) MarshalText() ([]byte, error) {
+	return vc.appendText(nil), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, see Parse
+// for the accepted format. The callee is replaced by the decoded clock.
+func (vc *VClock) UnmarshalText(text []byte) error {
+	clock, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	*vc = clock
+	return nil
+}
+
+// appendText appends the text representation of the vector clock to dst.
+func (vc VClock) appendText(dst []byte) []byte {
+	dst = append(dst, '{')
+	for i, id := range vc.sortedIDs() {
+		dst = appendEntry(dst, i == 0, id, vc[id])
+	}
+	return append(dst, '}')
+}
+
+// appendEntry appends the text representation of a single entry of a clock to
+// dst, preceded by a separator unless it is the first entry. VClock,
+// SortedClock, and ImmutableClock share it so that they format clocks alike.
+func appendEntry(dst []byte, first bool, id string, ticks uint64) []byte {
+	if !first {
+		dst = append(dst, ", "...)
+	}
+	dst = appendQuoted(dst, id)
+	dst = append(dst, ':')
+	return strconv.AppendUint(dst, ticks, 10)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendQuoted appends s as a double-quoted JSON string to dst. Quotes,
+// backslashes, and control characters are escaped, all other characters are
+// copied verbatim. Just like in encoding/json, invalid UTF-8 is replaced by
+// the Unicode replacement character.
+func appendQuoted(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				dst = append(dst, '\\', c)
+			case c == '\n':
+				dst = append(dst, '\\', 'n')
+			case c == '\r':
+				dst = append(dst, '\\', 'r')
+			case c == '\t':
+				dst = append(dst, '\\', 't')
+			case c < 0x20:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			default:
+				dst = append(dst, c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, "\ufffd"...)
+		} else {
+			dst = append(dst, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(dst, '"')
+}
+
+// parser is a small hand-written parser for the text representation of
+// vector clocks.
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(offset int, format string, args ...interface{}) error {
+	return &SyntaxError{Offset: offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) unexpected() error {
+	if p.pos >= len(p.s) {
+		return p.errorf(p.pos, "unexpected end of input")
+	}
+	r, _ := utf8.DecodeRuneInString(p.s[p.pos:])
+	return p.errorf(p.pos, "unexpected character %q", r)
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips whitespace and the given character, if it is next.
+func (p *parser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parse() (VClock, error) {
+	if !p.consume('{') {
+		return nil, p.unexpected()
+	}
+
+	clock := New()
+	if !p.consume('}') {
+		for {
+			p.skipSpace()
+			start := p.pos
+			id, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := clock[id]; ok {
+				return nil, p.errorf(start, "duplicate id %q", id)
+			}
+
+			if !p.consume(':') {
+				return nil, p.unexpected()
+			}
+
+			p.skipSpace()
+			ticks, err := p.parseCounter()
+			if err != nil {
+				return nil, err
+			}
+			clock[id] = ticks
+
+			if p.consume('}') {
+				break
+			}
+			if !p.consume(',') {
+				return nil, p.unexpected()
+			}
+		}
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, p.unexpected()
+	}
+	return clock, nil
+}
+
+// parseString parses a double-quoted string starting at the current position.
+func (p *parser) parseString() (string, error) {
+	if p.pos >= len(p.s) || p.s[p.pos] != '"' {
+		return "", p.unexpected()
+	}
+	p.pos++
+
+	var b []byte
+	for {
+		if p.pos >= len(p.s) {
+			return "", p.errorf(p.pos, "unterminated string")
+		}
+
+		c := p.s[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return string(b), nil
+		case c < 0x20:
+			return "", p.errorf(p.pos, "invalid control character %q in string", c)
+		case c >= utf8.RuneSelf:
+			// ids must survive a round trip through ReturnVCString, which
+			// cannot represent invalid UTF-8
+			r, size := utf8.DecodeRuneInString(p.s[p.pos:])
+			if r == utf8.RuneError && size == 1 {
+				return "", p.errorf(p.pos, "invalid UTF-8 in string")
+			}
+			b = append(b, p.s[p.pos:p.pos+size]...)
+			p.pos += size
+			continue
+		case c != '\\':
+			b = append(b, c)
+			p.pos++
+			continue
+		}
+
+		start := p.pos
+		p.pos++
+		if p.pos >= len(p.s) {
+			return "", p.errorf(p.pos, "unterminated string")
+		}
+
+		switch p.s[p.pos] {
+		case '"', '\\', '/':
+			b = append(b, p.s[p.pos])
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'u':
+			r, ok := p.parseHex(p.pos + 1)
+			if !ok {
+				return "", p.errorf(start, "invalid unicode escape")
+			}
+			p.pos += 4
+
+			if utf16.IsSurrogate(r) {
+				// a surrogate pair is written as two consecutive escapes
+				r2, ok := p.parseHex(p.pos + 3)
+				if ok && p.s[p.pos+1] == '\\' && p.s[p.pos+2] == 'u' {
+					if dec := utf16.DecodeRune(r, r2); dec != utf8.RuneError {
+						r = dec
+						p.pos += 6
+					} else {
+						r = utf8.RuneError
+					}
+				} else {
+					r = utf8.RuneError
+				}
+			}
+			b = utf8.AppendRune(b, r)
+		default:
+			return "", p.errorf(start, "invalid escape sequence %q", p.s[start:p.pos+1])
+		}
+		p.pos++
+	}
+}
+
+// parseHex parses the four hexadecimal digits of a unicode escape at offset.
+func (p *parser) parseHex(offset int) (rune, bool) {
+	if offset+4 > len(p.s) {
+		return 0, false
+	}
+
+	var r rune
+	for _, c := range []byte(p.s[offset : offset+4]) {
+		switch {
+		case '0' <= c && c <= '9':
+			c -= '0'
+		case 'a' <= c && c <= 'f':
+			c = c - 'a' + 10
+		case 'A' <= c && c <= 'F':
+			c = c - 'A' + 10
+		default:
+			return 0, false
+		}
+		r = r<<4 | rune(c)
+	}
+	return r, true
+}
+
+// parseCounter parses a non-negative decimal integer starting at the current
+// position.
+func (p *parser) parseCounter() (uint64, error) {
+	start := p.pos
+	for p.pos < len(p.s) && '0' <= p.s[p.pos] && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	digits := p.s[start:p.pos]
+
+	if p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '-':
+			if len(digits) == 0 {
+				return 0, p.errorf(start, "negative counter")
+			}
+		case '.', 'e', 'E':
+			if len(digits) > 0 {
+				return 0, p.errorf(start, "counter is not an integer")
+			}
+		}
+	}
+
+	if len(digits) == 0 {
+		return 0, p.unexpected()
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return 0, p.errorf(start, "counter has leading zeros")
+	}
+
+	ticks, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, p.errorf(start, "counter %s is out of range", digits)
+	}
+	return ticks, nil
+}
@@ -0,0 +1,228 @@
+package vclock
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var (
+	_ encoding.TextMarshaler   = VClock{}
+	_ encoding.TextUnmarshaler = &VClock{}
+)
+
+func TestVCStringEscaping(t *testing.T) {
+	n := New()
+	n.Set("a\"b", 1)
+	n.Set("c\\d", 2)
+	n.Set("e\nf\x01", 3)
+	n.Set("grüße", 4)
+
+	expected := "{\"a\\\"b\":1, \"c\\\\d\":2, \"e\\nf\\u0001\":3, \"grüße\":4}"
+	nString := n.ReturnVCString()
+
+	if nString != expected {
+		t.Fatalf("VC string %s not the same as expected %s", nString, expected)
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	n := genVClock(100)
+	n.Set("", 1)
+	n.Set("quote\"", 2)
+	n.Set("back\\slash", 3)
+	n.Set("tab\tnewline\n", 4)
+	n.Set("日本語", 5)
+	n.Set("😀", 18446744073709551615)
+
+	decoded, err := Parse(n.ReturnVCString())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !n.Compare(decoded, Equal) {
+		failComparison(t, "decoded not the same as encoded enc = %s | dec = %s", n, decoded)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	n := New()
+	n.Set("a\"b", 1)
+	n.Set("😀", 2)
+
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := Parse(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !n.Compare(decoded, Equal) {
+		failComparison(t, "decoded not the same as encoded enc = %s | dec = %s", n, decoded)
+	}
+}
+
+func TestParseEscapes(t *testing.T) {
+	tests := map[string]string{
+		`{"\u0041":1}`:        "A",
+		`{"\u00fc":1}`:        "\u00fc",
+		`{"\ud83d\ude00":1}`:  "\U0001F600",
+		`{"\ud83d":1}`:        "\ufffd",
+		`{"\ud83dA":1}`:       "\ufffdA",
+		`{"\/\b\f\r\t":1}`:    "/\b\f\r\t",
+		` { "a" : 1 } `:       "a",
+		"{\n\t\"a\":1\r\n}\n": "a",
+	}
+
+	for input, id := range tests {
+		decoded, err := Parse(input)
+		if err != nil {
+			t.Fatalf("%s: %v", input, err)
+		}
+		if _, ok := decoded.FindTicks(id); !ok || len(decoded) != 1 {
+			t.Fatalf("%s: expected id %q, got %s", input, id, decoded.ReturnVCString())
+		}
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	decoded, err := Parse("{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded == nil || len(decoded) != 0 {
+		t.Fatalf("expected empty clock, got %s", decoded.ReturnVCString())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int
+	}{
+		{"", 0},
+		{"[]", 0},
+		{"{", 1},
+		{"{\"a\":1", 6},
+		{"{\"a\":1,}", 7},
+		{"{\"a\" 1}", 5},
+		{"{\"a\":}", 5},
+		{"{\"a\":-1}", 5},
+		{"{\"a\":1.5}", 5},
+		{"{\"a\":1e3}", 5},
+		{"{\"a\":01}", 5},
+		{"{\"a\":18446744073709551616}", 5},
+		{"{\"a\":1, \"b\":2, \"a\":3}", 15},
+		{"{\"a\\x\":1}", 3},
+		{"{\"a\\u00g0\":1}", 3},
+		{"{\"a\nb\":1}", 3},
+		{"{\"ab", 4},
+		{"{\"a\xffb\":1}", 3},
+		{"{a:1}", 1},
+		{"{\"a\":1}x", 7},
+		{"{\"a\":1}{}", 7},
+	}
+
+	for _, test := range tests {
+		decoded, err := Parse(test.input)
+		if err == nil {
+			t.Fatalf("%q: expected error, got %s", test.input, decoded.ReturnVCString())
+		}
+
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("%q: expected *SyntaxError, got %T", test.input, err)
+		}
+		if syntaxErr.Offset != test.offset {
+			t.Fatalf("%q: expected offset %d, got %d (%v)", test.input, test.offset, syntaxErr.Offset, err)
+		}
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	n := genVClock(10)
+
+	text, err := n.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(text) != n.ReturnVCString() {
+		t.Fatalf("text %s not the same as VC string %s", text, n.ReturnVCString())
+	}
+
+	var decoded VClock
+	if err := decoded.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+
+	if !n.Compare(decoded, Equal) {
+		failComparison(t, "decoded not the same as encoded enc = %s | dec = %s", n, decoded)
+	}
+}
+
+func TestParseUTF8RoundTrip(t *testing.T) {
+	for _, s := range []string{
+		"{\"h\u00e9\":1}",
+		"{\"\u65e5\u672c\":2}",
+		"{\"\\ud83d\\ude00\":3}",
+		"{\"\\u00e9\\u0000\":4}",
+	} {
+		vc, err := Parse(s)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %v", s, err)
+		}
+
+		parsed, err := Parse(vc.ReturnVCString())
+		if err != nil {
+			t.Fatalf("cannot parse %s: %v", vc.ReturnVCString(), err)
+		}
+		if parsed.Order(vc) != Equal {
+			failComparison(t, "parsed %s not the same as %s", parsed, vc)
+		}
+	}
+
+	for _, s := range []string{"{\"\xa0\":0}", "{\"a\xc3\":1}", "{\"\xed\xa0\x80\":1}"} {
+		if vc, err := Parse(s); err == nil {
+			t.Fatalf("expected error for %q, got %s", s, vc.ReturnVCString())
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range []string{
+		"{}",
+		"{\"a\":1}",
+		"{\"a\":1, \"b\":18446744073709551615}",
+		" { \"a\\\"b\" : 2 } ",
+		"{\"a\":01}",
+		"{\"a\":1,}",
+	} {
+		f.Add(s)
+	}
+	f.Add(New().ReturnVCString())
+
+	f.Fuzz(func(t *testing.T, s string) {
+		vc, err := Parse(s)
+		if err != nil {
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || syntaxErr.Offset < 0 || syntaxErr.Offset > len(s) {
+				t.Fatalf("unexpected error for %q: %v", s, err)
+			}
+			return
+		}
+
+		parsed, err := Parse(vc.ReturnVCString())
+		if err != nil {
+			t.Fatalf("cannot parse %s: %v", vc.ReturnVCString(), err)
+		}
+		if parsed.Order(vc) != Equal {
+			failComparison(t, "parsed %s not the same as %s", parsed, vc)
+		}
+	})
+}
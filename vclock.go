@@ -30,8 +30,8 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
-	"log"
 	"sort"
+	"strings"
 )
 
 // Condition constants define how to compare a vector clock against another,
@@ -47,6 +47,31 @@ const (
 	Concurrent
 )
 
+// String returns the name of the condition, e.g., "Descendant". ORed
+// conditions are joined with "|", e.g., "Equal|Descendant".
+func (c Condition) String() string {
+	var names []string
+	for _, n := range []struct {
+		cond Condition
+		name string
+	}{
+		{Equal, "Equal"},
+		{Ancestor, "Ancestor"},
+		{Descendant, "Descendant"},
+		{Concurrent, "Concurrent"},
+	} {
+		if c&n.cond != 0 {
+			names = append(names, n.name)
+			c &^= n.cond
+		}
+	}
+
+	if c != 0 || len(names) == 0 {
+		names = append(names, fmt.Sprintf("Condition(%d)", int(c)))
+	}
+	return strings.Join(names, "|")
+}
+
 // VClock is the type of a vector clock. A VClock is a map of process ids (string)
 // to clock values (uint64). Note that this implies that the zero clock value is 0.
 type VClock map[string]uint64
@@ -116,54 +141,52 @@ func (vc VClock) Merge(other VClock) {
 	}
 }
 
-// Bytes returns an encoded vector clock using the gob package.
+// Bytes returns an encoded vector clock using the compact binary encoding.
+// It is a thin wrapper around MarshalBinary for compatibility, use
+// MarshalBinary, AppendBytes, or WriteTo if you need to handle errors.
 func (vc VClock) Bytes() []byte {
-	b := new(bytes.Buffer)
-	enc := gob.NewEncoder(b)
-	err := enc.Encode(vc)
-	if err != nil {
-		log.Fatal("Vector Clock Encode:", err)
-	}
-	return b.Bytes()
+	b, _ := vc.MarshalBinary()
+	return b
 }
 
-// FromBytes decodes a vector clock from a byte slice using the gob package.
+// FromBytes decodes a vector clock from a byte slice. It accepts both the
+// compact binary encoding returned by Bytes and the gob encoding that was
+// used by earlier versions of this package.
 func FromBytes(data []byte) (vc VClock, err error) {
-	b := new(bytes.Buffer)
-	b.Write(data)
-	clock := New()
-	dec := gob.NewDecoder(b)
+	if isCompactBinary(data) {
+		clock := New()
+		err = clock.UnmarshalBinary(data)
+		return clock, err
+	}
+
+	// decode into a plain map: gob would call UnmarshalBinary for a VClock,
+	// but earlier versions encoded the map itself
+	clock := map[string]uint64{}
+	dec := gob.NewDecoder(bytes.NewReader(data))
 	err = dec.Decode(&clock)
 	return clock, err
 }
 
+// sortedIDs returns the process ids of the vector clock in ascending order.
+func (vc VClock) sortedIDs() []string {
+	ids := make([]string, 0, len(vc))
+	for id := range vc {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // PrintVC prints the callees vector clock to stdout.
 func (vc VClock) PrintVC() {
 	fmt.Println(vc.ReturnVCString())
 }
 
 // ReturnVCString returns a deterministic string encoding of a vector clock.
+// Process ids are sorted and quoted like JSON strings, so the output can be
+// read back with Parse.
 func (vc VClock) ReturnVCString() string {
-	//sort
-	ids := make([]string, len(vc))
-	i := 0
-	for id := range vc {
-		ids[i] = id
-		i++
-	}
-
-	sort.Strings(ids)
-
-	var buffer bytes.Buffer
-	buffer.WriteString("{")
-	for i := range ids {
-		buffer.WriteString(fmt.Sprintf("\"%s\":%d", ids[i], vc[ids[i]]))
-		if i+1 < len(ids) {
-			buffer.WriteString(", ")
-		}
-	}
-	buffer.WriteString("}")
-	return buffer.String()
+	return string(vc.appendText(nil))
 }
 
 // Order determines the relationship between two clocks. It returns
@@ -189,49 +212,52 @@ func (vc VClock) Order(other VClock) Condition {
 	vcBigger := false
 	otherBigger := false
 
-	// we're finding the entries that both clocks have in common
-	commonEntries := make(map[string]struct{})
+	// Instead of collecting the entries that both clocks have in common, we
+	// only count them, which keeps Order free of heap allocations.
+	common := 0
 
-	for id := range vc {
-		if _, ok := other[id]; ok {
-			commonEntries[id] = struct{}{}
-		}
-	}
-
-	if len(vc) > len(commonEntries) {
-		vcBigger = true
-	}
-	if len(other) > len(commonEntries) {
-		otherBigger = true
-	}
+	for id, vcVersion := range vc {
+		otherVersion, ok := other[id]
+		if !ok {
+			vcBigger = true
+		} else {
+			common++
 
-	for id := range commonEntries {
-		if vcBigger && otherBigger {
-			break
+			if vcVersion > otherVersion {
+				vcBigger = true
+			} else if vcVersion < otherVersion {
+				otherBigger = true
+			}
 		}
-		vcVersion := vc[id]
-		otherVersion := other[id]
 
-		if vcVersion > otherVersion {
-			vcBigger = true
-		} else if vcVersion < otherVersion {
-			otherBigger = true
+		if vcBigger && otherBigger {
+			return Concurrent
 		}
 	}
 
-	if !vcBigger && !otherBigger {
-		return Equal
+	// other has entries that vc does not have
+	if len(other) > common {
+		otherBigger = true
 	}
 
-	if vcBigger && !otherBigger {
-		return Ancestor
-	}
+	return condition(vcBigger, otherBigger)
+}
 
-	if !vcBigger && otherBigger {
+// condition returns the relationship of two clocks a and b for the Order
+// methods of all clock types, given whether a has an entry that is greater
+// than the one of b, and whether b has an entry that is greater than the one
+// of a.
+func condition(aBigger, bBigger bool) Condition {
+	switch {
+	case !aBigger && !bBigger:
+		return Equal
+	case aBigger && !bBigger:
+		return Ancestor
+	case !aBigger && bBigger:
 		return Descendant
+	default:
+		return Concurrent
 	}
-
-	return Concurrent
 }
 
 // Compare takes another clock ("other") and determines if it is Equal, an
@@ -244,6 +270,55 @@ func (vc VClock) Compare(other VClock, cond Condition) bool {
 	return vc.Order(other)&cond != 0
 }
 
+// OrderNormalized determines the relationship between two clocks just like
+// Order, but treats a process id that is missing from a clock the same as an
+// entry with a clock value of 0. For example, {"A":1} and {"A":1, "B":0} are
+// Equal according to OrderNormalized, while Order considers the latter a
+// descendant of the former.
+func (vc VClock) OrderNormalized(other VClock) Condition {
+	vcBigger := false
+	otherBigger := false
+
+	for id, vcVersion := range vc {
+		otherVersion := other[id]
+
+		if vcVersion > otherVersion {
+			vcBigger = true
+		} else if vcVersion < otherVersion {
+			otherBigger = true
+		}
+	}
+
+	// entries that are missing from vc only matter if they are not 0
+	for id, otherVersion := range other {
+		if otherBigger {
+			break
+		}
+		if _, ok := vc[id]; !ok && otherVersion > 0 {
+			otherBigger = true
+		}
+	}
+
+	return condition(vcBigger, otherBigger)
+}
+
+// CompareNormalized is like Compare, but uses OrderNormalized to determine
+// the relationship between the clocks.
+func (vc VClock) CompareNormalized(other VClock, cond Condition) bool {
+	return vc.OrderNormalized(other)&cond != 0
+}
+
+// Normalize removes all entries with a clock value of 0 from the vector clock.
+// After normalizing two clocks, Order gives the same result as OrderNormalized.
+// Normalize updates the callee vector clock in place.
+func (vc VClock) Normalize() {
+	for id, ticks := range vc {
+		if ticks == 0 {
+			delete(vc, id)
+		}
+	}
+}
+
 // CompareOld takes another clock and determines if it is Equal, an
 // Ancestor, Descendant, or Concurrent with the callees clock.
 // Deprecated: This is the original implementation of Compare, which is now